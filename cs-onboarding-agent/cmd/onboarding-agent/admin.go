@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// adminTokenHeader carries the admin token. It is a header of its own so
+// it doesn't clash with the user's bearer token, and browsers can't send it
+// cross-origin since CORS doesn't allow it.
+const adminTokenHeader = "X-Admin-Token"
+
+// adminPathPrefix is where the admin endpoints live, both those the agent
+// serves itself and those of the onboarding service.
+const adminPathPrefix = "/api/v1/admin/"
+
+// cliAdminToken is the admin token the CLI's admin commands send.
+var cliAdminToken string
+
+// addAdminTokenFlag adds --admin-token to an admin command.
+func addAdminTokenFlag(flags *pflag.FlagSet) {
+	flags.StringVar(&cliAdminToken, "admin-token", os.Getenv("ONBOARDING_ADMIN_TOKEN"), "Admin token of the server (env ONBOARDING_ADMIN_TOKEN)")
+}
+
+// loadAdminToken reads the token that admin requests must present.
+func loadAdminToken(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("'%s' is empty", name)
+	}
+	return token, nil
+}
+
+// adminMiddleware guards every endpoint under adminPathPrefix, which can
+// take the service down or change what it serves. It is installed on the
+// whole router so routes the onboarding service registers are covered too.
+// Without a configured token they are disabled altogether.
+func adminMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, adminPathPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if token == "" {
+				writeError(w, r, http.StatusForbidden, "admin endpoints are disabled, start the server with --admin-token-file")
+				return
+			}
+			given := r.Header.Get(adminTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				writeError(w, r, http.StatusUnauthorized, "a valid "+adminTokenHeader+" header is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
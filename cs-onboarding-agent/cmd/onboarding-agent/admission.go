@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// admissionConfig bounds how many chat messages are processed at once.
+// Messages over MaxConcurrent wait, up to MaxWaiting of them for at most
+// WaitTimeout; the rest are shed with a 503 so a stampede of sessions slows
+// the server down instead of exhausting its memory.
+type admissionConfig struct {
+	MaxConcurrent int    `json:"max_concurrent,omitempty"` // 0 disables admission control
+	MaxWaiting    int    `json:"max_waiting,omitempty"`
+	WaitTimeout   string `json:"wait_timeout,omitempty"`
+
+	waitTimeout time.Duration
+}
+
+func (c *admissionConfig) validate() error {
+	if c.MaxConcurrent < 0 || c.MaxWaiting < 0 {
+		return fmt.Errorf("admission limits must not be negative")
+	}
+	c.waitTimeout = 0
+	if c.WaitTimeout != "" {
+		timeout, err := time.ParseDuration(c.WaitTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid admission wait timeout '%s': %w", c.WaitTimeout, err)
+		}
+		c.waitTimeout = timeout
+	}
+	return nil
+}
+
+// admittedPaths are the requests that do the expensive message processing.
+var admittedPaths = []string{"/api/v1/onboarding/message", "/api/v1/onboarding/command"}
+
+// admissionController counts the messages being processed and waiting.
+// Limits are read from the current configuration on every request so a
+// reload resizes them without dropping anything already admitted.
+type admissionController struct {
+	mu       sync.Mutex
+	inFlight int
+	waiting  int
+	// released is closed and replaced whenever a slot frees up, waking
+	// every waiter to compete for it
+	released chan struct{}
+}
+
+var admission = &admissionController{released: make(chan struct{})}
+
+// acquire admits a request, waiting for a slot if allowed. It returns false
+// if the request should be shed.
+func (a *admissionController) acquire(ctx context.Context, config admissionConfig) bool {
+	a.mu.Lock()
+	if a.inFlight < config.MaxConcurrent {
+		a.inFlight++
+		a.mu.Unlock()
+		return true
+	}
+	if a.waiting >= config.MaxWaiting || config.waitTimeout <= 0 {
+		a.mu.Unlock()
+		return false
+	}
+	a.waiting++
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.waiting--
+		a.mu.Unlock()
+	}()
+
+	deadline := time.NewTimer(config.waitTimeout)
+	defer deadline.Stop()
+	for {
+		a.mu.Lock()
+		if a.inFlight < config.MaxConcurrent {
+			a.inFlight++
+			a.mu.Unlock()
+			return true
+		}
+		released := a.released
+		a.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-deadline.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (a *admissionController) release() {
+	a.mu.Lock()
+	a.inFlight--
+	close(a.released)
+	a.released = make(chan struct{})
+	a.mu.Unlock()
+}
+
+// admissionMiddleware applies admission control to message processing.
+// Shed requests get a 503 with Retry-After so clients back off.
+func admissionMiddleware(logger logging.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			config := currentConfig().Admission
+			// Messages replayed after maintenance run one at a time and
+			// must not be shed, so they bypass admission
+			if config.MaxConcurrent == 0 || r.Method != http.MethodPost || !isAdmittedPath(r.URL.Path) ||
+				r.Context().Value(replayKey{}) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !admission.acquire(r.Context(), config) {
+				logger.Warn(r.Context(), "Shedding %s %s, too many messages in progress", r.Method, r.URL.Path)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(config)))
+				writeError(w, r, http.StatusServiceUnavailable, "the server is busy, please try again shortly")
+				return
+			}
+			defer admission.release()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAdmittedPath(path string) bool {
+	for _, prefix := range admittedPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfterSeconds suggests a retry once the waiting messages had their
+// chance, never less than a second.
+func retryAfterSeconds(config admissionConfig) int {
+	seconds := int(config.waitTimeout.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newAdmissionConfig(t *testing.T, maxConcurrent, maxWaiting int, waitTimeout string) admissionConfig {
+	t.Helper()
+	config := admissionConfig{MaxConcurrent: maxConcurrent, MaxWaiting: maxWaiting, WaitTimeout: waitTimeout}
+	if err := config.validate(); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// waitForWaiters blocks until n requests are queued for a slot, reporting
+// false if that doesn't happen within a few seconds.
+func waitForWaiters(a *admissionController, n int) bool {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		a.mu.Lock()
+		waiting := a.waiting
+		a.mu.Unlock()
+		if waiting == n {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAdmissionSlots(t *testing.T) {
+	a := &admissionController{released: make(chan struct{})}
+	config := newAdmissionConfig(t, 2, 0, "")
+	ctx := context.Background()
+
+	if !a.acquire(ctx, config) || !a.acquire(ctx, config) {
+		t.Fatal("expected the first two requests to be admitted")
+	}
+	// Without a queue, a request over the limit is shed right away
+	if a.acquire(ctx, config) {
+		t.Fatal("expected the third request to be shed")
+	}
+	a.release()
+	if !a.acquire(ctx, config) {
+		t.Fatal("expected a request to be admitted once a slot is released")
+	}
+}
+
+func TestAdmissionQueue(t *testing.T) {
+	a := &admissionController{released: make(chan struct{})}
+	config := newAdmissionConfig(t, 1, 1, "5s")
+	ctx := context.Background()
+
+	if !a.acquire(ctx, config) {
+		t.Fatal("expected the first request to be admitted")
+	}
+	admitted := make(chan bool)
+	go func() { admitted <- a.acquire(ctx, config) }()
+	if !waitForWaiters(a, 1) {
+		t.Fatal("expected the second request to wait")
+	}
+
+	// The queue is full, so the next one is shed without waiting
+	start := time.Now()
+	if a.acquire(ctx, config) {
+		t.Fatal("expected a request beyond the queue to be shed")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shedding took %s, it shouldn't wait", elapsed)
+	}
+
+	a.release()
+	if !<-admitted {
+		t.Fatal("expected the waiting request to get the released slot")
+	}
+	if !waitForWaiters(a, 0) {
+		t.Error("the admitted request is still counted as waiting")
+	}
+}
+
+func TestAdmissionWaitEnds(t *testing.T) {
+	a := &admissionController{released: make(chan struct{})}
+	if !a.acquire(context.Background(), newAdmissionConfig(t, 1, 1, "")) {
+		t.Fatal("expected the first request to be admitted")
+	}
+
+	if a.acquire(context.Background(), newAdmissionConfig(t, 1, 1, "20ms")) {
+		t.Error("expected the request to be shed after the wait timeout")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waitForWaiters(a, 1)
+		cancel()
+	}()
+	if a.acquire(ctx, newAdmissionConfig(t, 1, 1, "5s")) {
+		t.Error("expected the request to be shed when the client goes away")
+	}
+	if !waitForWaiters(a, 0) {
+		t.Error("the shed request is still counted as waiting")
+	}
+}
+
+func TestAdmissionMiddleware(t *testing.T) {
+	previous := activeConfig.Load()
+	activeConfig.Store(&serverConfig{Admission: newAdmissionConfig(t, 1, 0, "")})
+	t.Cleanup(func() { activeConfig.Store(previous) })
+
+	logger, err := newReloadableLogger("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := admissionMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	if code := serve(http.MethodPost, "/api/v1/onboarding/message").Code; code != http.StatusOK {
+		t.Fatalf("expected a message to be admitted, got %d", code)
+	}
+
+	// Hold the only slot, as a message in progress would
+	if !admission.acquire(context.Background(), currentConfig().Admission) {
+		t.Fatal("expected the slot to be free again")
+	}
+	defer admission.release()
+
+	shed := serve(http.MethodPost, "/api/v1/onboarding/message")
+	if shed.Code != http.StatusServiceUnavailable || shed.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 503 with Retry-After 1, got %d with %q", shed.Code, shed.Header().Get("Retry-After"))
+	}
+	// Only message processing is limited
+	if code := serve(http.MethodGet, "/api/v1/onboarding/status/s1").Code; code != http.StatusOK {
+		t.Errorf("expected a status request to pass, got %d", code)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// airGappedConfig is the air_gapped section of the configuration file. In
+// air-gapped mode the server only talks to internal mirrors: OCM must point
+// at one, and every other outbound request is refused unless its host is
+// allowed.
+type airGappedConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// AllowedHosts are the mirrors and internal services that may be
+	// reached, as host, host:port or *.domain. The OCM gateway and token
+	// hosts are always allowed, and so is loopback.
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+}
+
+func (c *airGappedConfig) validate(ocm ocmConfig) error {
+	if !c.Enabled {
+		return nil
+	}
+	// The SDK defaults are the production gateway and SSO
+	if ocm.URL == "" || ocm.TokenURL == "" {
+		return fmt.Errorf("air-gapped mode needs the OCM gateway and token URLs of an internal mirror, set --ocm-url and --ocm-token-url or ocm.url and ocm.token_url in the configuration file")
+	}
+	for _, host := range c.AllowedHosts {
+		// A wildcard only stands for whole labels, so *example.com is
+		// refused rather than let evilexample.com through
+		name := strings.TrimPrefix(host, "*.")
+		if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, "/*") {
+			return fmt.Errorf("invalid allowed host '%s', expected host, host:port or *.domain", host)
+		}
+	}
+	return nil
+}
+
+// allowedHosts returns the configured hosts plus those of the OCM mirror.
+func (c *airGappedConfig) allowedHosts(ocm ocmConfig) []string {
+	hosts := append([]string{}, c.AllowedHosts...)
+	for _, raw := range []string{ocm.URL, ocm.TokenURL} {
+		if u, err := url.Parse(raw); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts
+}
+
+// egressGuard refuses HTTP requests to hosts that aren't allowed, so a
+// missed integration fails fast and visibly instead of hanging on a
+// firewall. It only covers the transports it is explicitly wrapped around;
+// it doesn't touch http.DefaultTransport, and it is no substitute for a
+// network policy.
+type egressGuard struct {
+	allowed []string
+	logger  logging.Logger
+}
+
+func newEgressGuard(allowed []string, logger logging.Logger) *egressGuard {
+	return &egressGuard{allowed: allowed, logger: logger}
+}
+
+func (g *egressGuard) wrap(base http.RoundTripper) http.RoundTripper {
+	return &guardedTransport{guard: g, base: base}
+}
+
+type guardedTransport struct {
+	guard *egressGuard
+	base  http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.guard.allows(req.URL) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		t.guard.logger.Warn(req.Context(), "Refused outbound request to %s in air-gapped mode", req.URL.Host)
+		return nil, fmt.Errorf("air-gapped mode: outbound requests to %s are not allowed", req.URL.Host)
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (g *egressGuard) allows(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	for _, entry := range g.allowed {
+		entryHost, entryPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			entryHost, entryPort = entry, ""
+		}
+		entryHost = strings.ToLower(entryHost)
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if base, ok := strings.CutPrefix(entryHost, "*."); ok {
+			if host == base || strings.HasSuffix(host, "."+base) {
+				return true
+			}
+			continue
+		}
+		if host == entryHost {
+			return true
+		}
+	}
+	return false
+}
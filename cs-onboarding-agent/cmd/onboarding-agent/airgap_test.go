@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEgressGuardAllows(t *testing.T) {
+	guard := newEgressGuard([]string{
+		"mirror.corp.example.com",
+		"jira.corp.example.com:8443",
+		"*.apps.example.com",
+	}, nil)
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://mirror.corp.example.com/api", true},
+		{"https://MIRROR.corp.example.com/api", true},
+		// A host without a port is allowed on any port
+		{"https://mirror.corp.example.com:8443/api", true},
+		{"https://jira.corp.example.com:8443/rest", true},
+		{"https://jira.corp.example.com/rest", false},
+		{"https://console.apps.example.com", true},
+		{"https://a.b.apps.example.com", true},
+		{"https://apps.example.com", true},
+		// Look-alikes that only share a suffix with an allowed domain
+		{"https://evilapps.example.com", false},
+		{"https://console.apps.example.com.evil.io", false},
+		{"https://notmirror.corp.example.com", false},
+		{"http://localhost:8080/health", true},
+		{"http://127.0.0.1:9000", true},
+		{"https://api.openshift.com", false},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := guard.allows(u); got != test.want {
+			t.Errorf("allows(%s) = %v, want %v", test.url, got, test.want)
+		}
+	}
+}
+
+func TestAirGappedConfigValidateHosts(t *testing.T) {
+	ocm := ocmConfig{URL: "https://ocm.corp.example.com", TokenURL: "https://sso.corp.example.com/token"}
+	tests := []struct {
+		host  string
+		valid bool
+	}{
+		{"mirror.corp.example.com", true},
+		{"jira.corp.example.com:8443", true},
+		{"*.apps.example.com", true},
+		{"*.apps.example.com:443", true},
+		{"*example.com", false},
+		{"*", false},
+		{"*.", false},
+		{"*..example.com", false},
+		{"apps.*.example.com", false},
+		{"", false},
+		{"https://mirror.corp.example.com", false},
+	}
+	for _, test := range tests {
+		config := airGappedConfig{Enabled: true, AllowedHosts: []string{test.host}}
+		if err := config.validate(ocm); (err == nil) != test.valid {
+			t.Errorf("allowed host %q: got error %v, want valid %v", test.host, err, test.valid)
+		}
+	}
+}
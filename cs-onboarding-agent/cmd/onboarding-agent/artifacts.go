@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Artifact describes a file the agent generated and stored against a
+// session, such as a cheat-sheet, completion certificate or exported config.
+type Artifact struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+var (
+	artifactSession string
+	artifactOutput  string
+	artifactForce   bool
+)
+
+// newArtifactsCommand builds the 'artifacts' group for fetching files the
+// agent generated during a session, such as personalized cheat-sheets.
+func newArtifactsCommand() *cobra.Command {
+	artifactsCmd := &cobra.Command{
+		Use:   "artifacts",
+		Short: "List and download files generated during an onboarding session",
+	}
+	artifactsCmd.PersistentFlags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	artifactsCmd.PersistentFlags().StringVar(&artifactSession, "session", "", "Session the artifacts belong to, defaulting to the most recent one")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the artifacts stored for a session",
+		Args:  cobra.NoArgs,
+		Run:   runArtifactsList,
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get [artifact-id]",
+		Short: "Download an artifact, e.g. the cheat-sheet offered at the end of a stage",
+		Args:  cobra.ExactArgs(1),
+		Run:   runArtifactsGet,
+	}
+	getCmd.Flags().StringVarP(&artifactOutput, "output", "o", "", "File to write to, '-' for stdout (default: the name suggested by the server)")
+	getCmd.Flags().BoolVar(&artifactForce, "force", false, "Overwrite the output file if it already exists")
+
+	artifactsCmd.AddCommand(listCmd, getCmd)
+	return artifactsCmd
+}
+
+// artifactSessionID returns the session given with --session or the one
+// remembered from the last interactive run.
+func artifactSessionID() string {
+	if artifactSession != "" {
+		return artifactSession
+	}
+	if profile, err := loadProfile(); err == nil && profile.SessionID != "" {
+		return profile.SessionID
+	}
+	fmt.Println("Please provide a session with --session, no recent session is known")
+	os.Exit(1)
+	return ""
+}
+
+func artifactPath(sessionID, artifactID string) string {
+	path := "/api/v1/onboarding/sessions/" + url.PathEscape(sessionID) + "/artifacts"
+	if artifactID != "" {
+		path += "/" + url.PathEscape(artifactID)
+	}
+	return path
+}
+
+func runArtifactsList(cmd *cobra.Command, args []string) {
+	var artifacts []Artifact
+	if err := callAPI(apiURL, http.MethodGet, artifactPath(artifactSessionID(), ""), nil, &artifacts); err != nil {
+		fmt.Printf("Failed to list artifacts: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(artifacts) == 0 {
+		fmt.Println("No artifacts")
+		return
+	}
+	fmt.Printf("%-24s %-14s %-32s %-8s %-20s %s\n", "ID", "KIND", "NAME", "SIZE", "CREATED", "EXPIRES")
+	for _, artifact := range artifacts {
+		expires := "never"
+		if artifact.ExpiresAt != nil {
+			expires = humanizeFromNow(*artifact.ExpiresAt, time.Now())
+		}
+		fmt.Printf("%-24s %-14s %-32s %-8s %-20s %s\n",
+			artifact.ID, artifact.Kind, artifact.Name, formatSize(artifact.Size),
+			artifact.CreatedAt.Local().Format("2006-01-02 15:04:05"), expires)
+	}
+}
+
+func runArtifactsGet(cmd *cobra.Command, args []string) {
+	artifactID := args[0]
+	path := artifactPath(artifactSessionID(), artifactID)
+
+	req, err := http.NewRequest(http.MethodGet, apiURL+path, nil)
+	if err != nil {
+		fmt.Printf("Failed to download artifact: %v\n", err)
+		os.Exit(1)
+	}
+	if err := authorize(req, apiURL); err != nil {
+		fmt.Printf("Failed to download artifact: %v\n", err)
+		os.Exit(1)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to download artifact: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("Failed to download artifact: %v\n", downloadError(resp))
+		os.Exit(1)
+	}
+
+	if artifactOutput == "-" {
+		if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+			fmt.Printf("Failed to download artifact: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	output := artifactOutput
+	if output == "" {
+		output, err = suggestedFilename(resp, artifactID)
+		if err != nil {
+			fmt.Printf("Failed to download artifact: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_EXCL
+	if artifactForce {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	file, err := os.OpenFile(output, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		fmt.Printf("Failed to download artifact: %s already exists, pass --force to overwrite it\n", output)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Failed to download artifact: %v\n", err)
+		os.Exit(1)
+	}
+	written, err := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+		fmt.Printf("Failed to download artifact: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved %s (%d bytes)\n", output, written)
+}
+
+// suggestedFilename takes the name from Content-Disposition, or the artifact
+// ID without one. Names with a path or a leading dot are refused, so the
+// server can't write outside the working directory or drop a dotfile there.
+func suggestedFilename(resp *http.Response, fallback string) (string, error) {
+	name := fallback
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = params["filename"]
+	}
+	if strings.ContainsAny(name, `/\:`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("refusing to save as '%s', pass --output to choose a file name", name)
+	}
+	return name, nil
+}
+
+// downloadError extracts the error from a failed download, which the server
+// reports like any other API error instead of returning the file.
+func downloadError(resp *http.Response) error {
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), problemContentType) {
+		return decodeProblem(resp)
+	}
+	var apiResp ApiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil || apiResp.Error == "" {
+		return fmt.Errorf("API error: %s", resp.Status)
+	}
+	return fmt.Errorf("API error: %s", apiResp.Error)
+}
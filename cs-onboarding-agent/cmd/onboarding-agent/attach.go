@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// attachmentTypes lists the content types that can be attached to a
+// session: logs and config snippets as text or JSON, and screenshots.
+var attachmentTypes = []string{
+	"text/plain",
+	"application/json",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+}
+
+type AttachmentResponse struct {
+	AttachmentID string `json:"attachment_id"`
+	Filename     string `json:"filename"`
+	Size         int64  `json:"size"`
+}
+
+// attachFile uploads a local file to the session so mentors (and the agent,
+// when enabled) can use it for troubleshooting.
+func attachFile(apiURL, sessionID, path string) error {
+	if path == "" {
+		return fmt.Errorf("usage: /attach <path>")
+	}
+
+	path = expandHome(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("'%s' is a directory", path)
+	}
+	if info.Size() > maxAttachmentSize {
+		return fmt.Errorf("'%s' is %d bytes, the limit is %d", path, info.Size(), maxAttachmentSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	contentType := detectAttachmentType(path, data)
+	if !allowedAttachmentType(contentType) {
+		return fmt.Errorf("files of type '%s' can't be attached", contentType)
+	}
+
+	// Logs are full of tokens, scrub text files the same way as messages
+	if strings.HasPrefix(contentType, "text/") || strings.HasPrefix(contentType, "application/json") {
+		data = []byte(scrubMessage(string(data)))
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("session_id", sessionID); err != nil {
+		return err
+	}
+	// CreateFormFile would label every file application/octet-stream
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteEscaper.Replace(filepath.Base(path))))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/api/v1/onboarding/attachments", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var attachment AttachmentResponse
+	if err := doAPIRequest(req, apiURL, &attachment); err != nil {
+		return err
+	}
+
+	fmt.Printf("Attached %s (%d bytes, id %s)\n", attachment.Filename, attachment.Size, attachment.AttachmentID)
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// detectAttachmentType sniffs the content type. Sniffing never reports
+// JSON, it is plain text to it, so text is taken as JSON if the file is
+// named .json or holds a JSON object or array.
+func detectAttachmentType(path string, data []byte) string {
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "text/plain") {
+		return contentType
+	}
+	trimmed := bytes.TrimSpace(data)
+	if strings.EqualFold(filepath.Ext(path), ".json") ||
+		(len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)) {
+		return "application/json"
+	}
+	return contentType
+}
+
+func allowedAttachmentType(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	for _, allowed := range attachmentTypes {
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHome resolves a leading ~ the way a shell would, since paths typed
+// into the chat don't go through one. It also drops the quotes Windows
+// Explorer's "Copy as path" puts around a path.
+func expandHome(path string) string {
+	if len(path) >= 2 && strings.HasPrefix(path, `"`) && strings.HasSuffix(path, `"`) {
+		path = path[1 : len(path)-1]
+	}
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditEntry is one line of the audit log, which records changes made to
+// the running server.
+type auditEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Actor is "SIGHUP" or the remote address of the admin request
+	Actor     string      `json:"actor"`
+	RequestID string      `json:"request_id,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// auditLog writes entries as JSON lines, to stderr unless --audit-log
+// names a file, so they stay apart from the service log.
+type auditLog struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+var audit = &auditLog{out: os.Stderr}
+
+// open appends to the named file from now on.
+func (a *auditLog) open(name string) error {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.out = f
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *auditLog) record(entry auditEntry) error {
+	entry.Time = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.out.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	bundleManifestName  = "manifest.json"
+	bundleSignatureName = "manifest.sig"
+)
+
+// bundleManifest is the first entry of a content bundle and lists every
+// file in it with its checksum. It is followed by its ed25519 signature, so
+// verifying the signature and the checksums verifies the whole bundle.
+type bundleManifest struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	Files     []bundleFile `json:"files"`
+}
+
+type bundleFile struct {
+	// Path is slash separated, under flows/ or docs/
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+var (
+	bundleFlowsDir string
+	bundleDocsDir  string
+	bundleOutput   string
+	bundleSignKey  string
+	bundleForce    bool
+)
+
+func newBundleCommand() *cobra.Command {
+	bundleCmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Package and load signed bundles of onboarding content",
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Package flows and docs into a bundle",
+		Args:  cobra.NoArgs,
+		Run:   runBundleCreate,
+	}
+	createCmd.Flags().StringVar(&bundleFlowsDir, "flows", "", "Directory with the flow definitions")
+	createCmd.Flags().StringVar(&bundleDocsDir, "docs", "", "Directory with the docs the flows link to")
+	createCmd.Flags().StringVarP(&bundleOutput, "output", "o", "onboarding-bundle.tar.gz", "File to write the bundle to")
+	createCmd.Flags().StringVar(&bundleSignKey, "sign-key", "", "PEM file with the ed25519 private key to sign the bundle with")
+	createCmd.Flags().BoolVar(&bundleForce, "force", false, "Overwrite the output file if it already exists")
+
+	loadCmd := &cobra.Command{
+		Use:   "load <bundle>",
+		Short: "Verify a bundle on the server and stage it in its content directory",
+		Args:  cobra.ExactArgs(1),
+		Run:   runBundleLoad,
+	}
+	loadCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	addAdminTokenFlag(loadCmd.Flags())
+
+	bundleCmd.AddCommand(createCmd, loadCmd)
+	return bundleCmd
+}
+
+func runBundleCreate(cmd *cobra.Command, args []string) {
+	if bundleFlowsDir == "" {
+		fmt.Println("Please provide --flows, the directory with the flow definitions")
+		os.Exit(1)
+	}
+	if bundleSignKey == "" {
+		fmt.Println("Please provide --sign-key, servers only load signed bundles")
+		os.Exit(1)
+	}
+	key, err := loadSigningKey(bundleSignKey)
+	if err != nil {
+		fmt.Printf("Failed to load signing key: %v\n", err)
+		os.Exit(1)
+	}
+	dirs := map[string]string{"flows": bundleFlowsDir}
+	if bundleDocsDir != "" {
+		dirs["docs"] = bundleDocsDir
+	}
+
+	manifest, sources, err := scanBundleContent(dirs)
+	if err != nil {
+		fmt.Printf("Failed to read bundle content: %v\n", err)
+		os.Exit(1)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_EXCL
+	if bundleForce {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	out, err := os.OpenFile(bundleOutput, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		fmt.Printf("Failed to create bundle: %s already exists, pass --force to overwrite it\n", bundleOutput)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Failed to create bundle: %v\n", err)
+		os.Exit(1)
+	}
+	err = writeBundle(out, manifest, sources, key)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(bundleOutput)
+		fmt.Printf("Failed to create bundle: %v\n", err)
+		os.Exit(1)
+	}
+
+	var size int64
+	for _, file := range manifest.Files {
+		size += file.Size
+	}
+	fmt.Printf("Wrote %s with %d files (%s)\n", bundleOutput, len(manifest.Files), formatSize(size))
+}
+
+// BundleLoadResponse describes the bundle the server staged.
+type BundleLoadResponse struct {
+	CreatedAt time.Time `json:"created_at"`
+	Files     int       `json:"files"`
+	LoadedAt  time.Time `json:"loaded_at"`
+}
+
+func runBundleLoad(cmd *cobra.Command, args []string) {
+	loaded, err := uploadBundle(apiURL, args[0])
+	if err != nil {
+		fmt.Printf("Failed to load bundle: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s Staged bundle from %s with %d files in the server's content directory\n", symbols().OK, formatTimestamp(loaded.CreatedAt, time.Now()), loaded.Files)
+	fmt.Println("Note: the server doesn't serve flows and docs from the content directory yet, the bundled content is not in use")
+}
+
+// uploadBundle sends a bundle to the server, which verifies it before
+// staging it.
+func uploadBundle(apiURL, name string) (*BundleLoadResponse, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("bundle", filepath.Base(name))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/api/v1/admin/bundle", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var loaded BundleLoadResponse
+	if err := doAPIRequest(req, apiURL, &loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+// loadSigningKey reads an ed25519 private key in PKCS #8 PEM form, as
+// written by 'openssl genpkey -algorithm ed25519'.
+func loadSigningKey(name string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("'%s' is not a PEM file", name)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse '%s': %w", name, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not an ed25519 key", name)
+	}
+	return key, nil
+}
+
+// scanBundleContent walks the content directories, keyed by their name in
+// the bundle, and checksums every file. It returns the manifest and the
+// local path of each file in it.
+func scanBundleContent(dirs map[string]string) (*bundleManifest, map[string]string, error) {
+	manifest := &bundleManifest{Version: 1, CreatedAt: time.Now().UTC()}
+	sources := map[string]string{}
+
+	var scanned []string
+	for _, prefix := range []string{"flows", "docs"} {
+		dir, ok := dirs[prefix]
+		if !ok {
+			continue
+		}
+		scanned = append(scanned, dir)
+		err := filepath.WalkDir(dir, func(local string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return fmt.Errorf("'%s' is not a regular file, bundles can't contain links or devices", local)
+			}
+
+			rel, err := filepath.Rel(dir, local)
+			if err != nil {
+				return err
+			}
+			name := path.Join(prefix, filepath.ToSlash(rel))
+			sum, size, err := checksumFile(local)
+			if err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, bundleFile{Path: name, Size: size, SHA256: sum})
+			sources[name] = local
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(manifest.Files) == 0 {
+		return nil, nil, fmt.Errorf("no files found in %s", strings.Join(scanned, " or "))
+	}
+	return manifest, sources, nil
+}
+
+func checksumFile(name string) (string, int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// writeBundle writes the manifest, its signature and the files it lists as
+// a gzipped tarball.
+func writeBundle(out io.Writer, manifest *bundleManifest, sources map[string]string, key ed25519.PrivateKey) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeBundleEntry(tw, bundleManifestName, manifest.CreatedAt, int64(len(data))); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	signature := ed25519.Sign(key, data)
+	if err := writeBundleEntry(tw, bundleSignatureName, manifest.CreatedAt, int64(len(signature))); err != nil {
+		return err
+	}
+	if _, err := tw.Write(signature); err != nil {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		if err := writeBundleEntry(tw, file.Path, manifest.CreatedAt, file.Size); err != nil {
+			return err
+		}
+		f, err := os.Open(sources[file.Path])
+		if err != nil {
+			return err
+		}
+		// Copy exactly the size in the header, a file that shrank since it
+		// was checksummed fails here
+		_, err = io.CopyN(tw, f, file.Size)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("can't copy '%s': %w", sources[file.Path], err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeBundleEntry(tw *tar.Writer, name string, modTime time.Time, size int64) error {
+	return tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: modTime,
+		Format:  tar.FormatPAX,
+	})
+}
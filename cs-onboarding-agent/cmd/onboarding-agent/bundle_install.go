@@ -0,0 +1,296 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// errStaleBundle rejects a bundle that isn't newer than the one in place.
+var errStaleBundle = errors.New("the bundle is not newer than the one in place")
+
+// maxManifestBytes bounds the manifest read into memory before it is
+// verified.
+const maxManifestBytes = 4 << 20
+
+// loadPublicKeys reads the ed25519 public keys bundles may be signed with,
+// in PKIX PEM form as written by 'openssl pkey -pubout'. One file may hold
+// several keys, e.g. while a signing key is being rotated.
+func loadPublicKeys(name string) ([]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var keys []ed25519.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse '%s': %w", name, err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("'%s' contains a key that is not ed25519", name)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public keys found in '%s'", name)
+	}
+	return keys, nil
+}
+
+// bundleInstaller unpacks verified bundles into the content directory. The
+// flow engine doesn't read flows and docs from there yet, so a loaded
+// bundle is staged for it rather than served.
+type bundleInstaller struct {
+	mu   sync.Mutex
+	dir  string
+	keys []ed25519.PublicKey
+}
+
+// install verifies the bundle while unpacking it next to the content
+// directory, then swaps it in. A bundle that fails verification, or isn't
+// newer than the one in place, leaves the content directory untouched.
+func (b *bundleInstaller) install(bundle io.Reader) (*bundleManifest, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	staging, err := os.MkdirTemp(filepath.Dir(b.dir), ".bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := unpackBundle(bundle, b.keys, staging)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only move forward, so an old bundle with a valid signature can't be
+	// replayed over newer content
+	current, err := b.current()
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && !manifest.CreatedAt.After(current.CreatedAt) {
+		return nil, fmt.Errorf("%w: it was created %s, the one in place %s",
+			errStaleBundle, manifest.CreatedAt.Format(time.RFC3339), current.CreatedAt.Format(time.RFC3339))
+	}
+
+	// Two renames rather than one, since a directory can't be renamed over
+	// one that isn't empty
+	previous := b.dir + ".previous"
+	if err := os.RemoveAll(previous); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(b.dir, previous); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := os.Rename(staging, b.dir); err != nil {
+		os.Rename(previous, b.dir)
+		return nil, err
+	}
+	os.RemoveAll(previous)
+	return manifest, nil
+}
+
+// current returns the manifest of the bundle in place, or nil if there is
+// none. It was verified when the bundle was installed.
+func (b *bundleInstaller) current() (*bundleManifest, error) {
+	data, err := os.ReadFile(filepath.Join(b.dir, bundleManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest in %s: %w", b.dir, err)
+	}
+	return &manifest, nil
+}
+
+// unpackBundle checks the manifest signature, then writes each file to dir
+// only as far as it matches the manifest. Anything not listed, listed
+// twice or missing fails the whole bundle.
+func unpackBundle(bundle io.Reader, keys []ed25519.PublicKey, dir string) (*bundleManifest, error) {
+	gz, err := gzip.NewReader(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	manifestData, err := readBundleEntry(tr, bundleManifestName, maxManifestBytes)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := readBundleEntry(tr, bundleSignatureName, ed25519.SignatureSize)
+	if err != nil {
+		return nil, err
+	}
+	if !verifySignature(keys, manifestData, signature) {
+		return nil, fmt.Errorf("the bundle is not signed by a trusted key")
+	}
+
+	var manifest bundleManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	expected := map[string]bundleFile{}
+	for _, file := range manifest.Files {
+		if !validBundlePath(file.Path) {
+			return nil, fmt.Errorf("invalid path '%s' in manifest", file.Path)
+		}
+		expected[file.Path] = file
+	}
+	if err := os.WriteFile(filepath.Join(dir, bundleManifestName), manifestData, 0o644); err != nil {
+		return nil, err
+	}
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt bundle: %w", err)
+		}
+		file, ok := expected[header.Name]
+		if !ok || header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("'%s' is not in the manifest", header.Name)
+		}
+		delete(expected, header.Name)
+		if err := extractBundleFile(tr, file, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	for name := range expected {
+		return nil, fmt.Errorf("'%s' is in the manifest but missing from the bundle", name)
+	}
+	return &manifest, nil
+}
+
+func readBundleEntry(tr *tar.Reader, name string, limit int64) ([]byte, error) {
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle: %w", err)
+	}
+	if header.Name != name {
+		return nil, fmt.Errorf("not a signed bundle, expected %s but found %s", name, header.Name)
+	}
+	if header.Size > limit {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	return io.ReadAll(tr)
+}
+
+func verifySignature(keys []ed25519.PublicKey, message, signature []byte) bool {
+	for _, key := range keys {
+		if ed25519.Verify(key, message, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// validBundlePath accepts the relative, slash separated paths under flows/
+// and docs/ that 'bundle create' writes, so nothing lands outside the
+// content directory.
+func validBundlePath(name string) bool {
+	if name != path.Clean(name) || strings.Contains(name, "\\") {
+		return false
+	}
+	return strings.HasPrefix(name, "flows/") || strings.HasPrefix(name, "docs/")
+}
+
+func extractBundleFile(r io.Reader, file bundleFile, dir string) error {
+	target := filepath.Join(dir, filepath.FromSlash(file.Path))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	// Read one byte past the expected size to notice a longer file
+	size, err := io.Copy(io.MultiWriter(out, hash), io.LimitReader(r, file.Size+1))
+	if err != nil {
+		return err
+	}
+	if size != file.Size || hex.EncodeToString(hash.Sum(nil)) != file.SHA256 {
+		return fmt.Errorf("'%s' doesn't match its checksum in the manifest", file.Path)
+	}
+	return out.Close()
+}
+
+// bundleLoadHandler accepts a bundle uploaded by 'bundle load' as the
+// multipart field "bundle".
+func bundleLoadHandler(installer *bundleInstaller, logger logging.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if installer == nil {
+			writeError(w, r, http.StatusNotImplemented, "bundle loading is not enabled, start the server with --content-dir and --bundle-public-key")
+			return
+		}
+
+		file, _, err := r.FormFile("bundle")
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "expected the bundle in the multipart field 'bundle': "+err.Error())
+			return
+		}
+		defer file.Close()
+
+		manifest, err := installer.install(file)
+		if err != nil {
+			logger.Warn(r.Context(), "Rejected content bundle: %v", err)
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, errStaleBundle) {
+				status = http.StatusConflict
+			}
+			writeError(w, r, status, err.Error())
+			return
+		}
+
+		logger.Info(r.Context(), "Staged content bundle created %s with %d files", manifest.CreatedAt.Format(time.RFC3339), len(manifest.Files))
+		err = audit.record(auditEntry{
+			Action:    "bundle.load",
+			Actor:     r.RemoteAddr,
+			RequestID: requestID(r.Context()),
+			Details:   map[string]interface{}{"created_at": manifest.CreatedAt, "files": len(manifest.Files)},
+		})
+		if err != nil {
+			logger.Error(r.Context(), "Failed to write audit log: %v", err)
+		}
+		writeData(w, r, http.StatusOK, BundleLoadResponse{
+			CreatedAt: manifest.CreatedAt,
+			Files:     len(manifest.Files),
+			LoadedAt:  time.Now().UTC(),
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testBundleFile struct {
+	name string
+	data string
+}
+
+func newTestKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// buildTestBundle lays a bundle out like writeBundle does. The manifest
+// lists files as given, then tamper may change the manifest or the files
+// before they are written, to build bundles 'bundle create' never would.
+// Without a key the signature is left out.
+func buildTestBundle(t *testing.T, key ed25519.PrivateKey, createdAt time.Time, files []testBundleFile, tamper func(*bundleManifest, *[]testBundleFile)) []byte {
+	t.Helper()
+	manifest := &bundleManifest{Version: 1, CreatedAt: createdAt}
+	for _, file := range files {
+		sum := sha256.Sum256([]byte(file.data))
+		manifest.Files = append(manifest.Files, bundleFile{Path: file.name, Size: int64(len(file.data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	if tamper != nil {
+		tamper(manifest, &files)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	gz := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gz)
+	write := func(name string, content []byte) {
+		if err := writeBundleEntry(tw, name, createdAt, int64(len(content))); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(bundleManifestName, data)
+	if key != nil {
+		write(bundleSignatureName, ed25519.Sign(key, data))
+	}
+	for _, file := range files {
+		write(file.name, []byte(file.data))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+var testBundleFiles = []testBundleFile{
+	{"flows/welcome.yaml", "name: welcome\n"},
+	{"docs/setup.md", "# Setup\n"},
+}
+
+func TestUnpackBundle(t *testing.T) {
+	key := newTestKey(t)
+	trusted := []ed25519.PublicKey{key.Public().(ed25519.PublicKey)}
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		description string
+		key         ed25519.PrivateKey
+		tamper      func(*bundleManifest, *[]testBundleFile)
+		wantErr     string
+	}{
+		{"valid bundle", key, nil, ""},
+		{"unsigned", nil, nil, "not a signed bundle"},
+		{"untrusted key", newTestKey(t), nil, "not signed by a trusted key"},
+		{"extra file", key, func(_ *bundleManifest, files *[]testBundleFile) {
+			*files = append(*files, testBundleFile{"flows/extra.yaml", "name: extra\n"})
+		}, "'flows/extra.yaml' is not in the manifest"},
+		{"missing file", key, func(_ *bundleManifest, files *[]testBundleFile) {
+			*files = (*files)[:1]
+		}, "'docs/setup.md' is in the manifest but missing"},
+		{"modified file", key, func(_ *bundleManifest, files *[]testBundleFile) {
+			(*files)[0].data = "name: changed\n"
+		}, "'flows/welcome.yaml' doesn't match its checksum"},
+		{"longer file", key, func(_ *bundleManifest, files *[]testBundleFile) {
+			(*files)[0].data += "more: true\n"
+		}, "'flows/welcome.yaml' doesn't match its checksum"},
+		{"parent directory in manifest", key, func(manifest *bundleManifest, files *[]testBundleFile) {
+			manifest.Files[0].Path = "flows/../../etc/cron.d/job"
+			(*files)[0].name = manifest.Files[0].Path
+		}, "invalid path 'flows/../../etc/cron.d/job'"},
+		{"absolute path in manifest", key, func(manifest *bundleManifest, files *[]testBundleFile) {
+			manifest.Files[0].Path = "/etc/cron.d/job"
+			(*files)[0].name = manifest.Files[0].Path
+		}, "invalid path '/etc/cron.d/job'"},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			files := append([]testBundleFile(nil), testBundleFiles...)
+			bundle := buildTestBundle(t, test.key, createdAt, files, test.tamper)
+			dir := t.TempDir()
+
+			manifest, err := unpackBundle(bytes.NewReader(bundle), trusted, dir)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected the bundle to unpack, got %v", err)
+				}
+				if len(manifest.Files) != len(testBundleFiles) {
+					t.Errorf("expected %d files in the manifest, got %d", len(testBundleFiles), len(manifest.Files))
+				}
+				data, err := os.ReadFile(filepath.Join(dir, "flows", "welcome.yaml"))
+				if err != nil || string(data) != testBundleFiles[0].data {
+					t.Errorf("flows/welcome.yaml wasn't unpacked as is: %q, %v", data, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidBundlePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"flows/welcome.yaml", true},
+		{"docs/guides/setup.md", true},
+		{"flows/../docs/setup.md", false},
+		{"../flows/welcome.yaml", false},
+		{"flows/../../etc/passwd", false},
+		{"/etc/passwd", false},
+		{"/flows/welcome.yaml", false},
+		{`flows\..\..\etc\passwd`, false},
+		{"./flows/welcome.yaml", false},
+		{"flows//welcome.yaml", false},
+		{"manifest.json", false},
+		{"flowsx/welcome.yaml", false},
+	}
+	for _, test := range tests {
+		if got := validBundlePath(test.path); got != test.want {
+			t.Errorf("validBundlePath(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestBundleInstallerRejectsStaleBundles(t *testing.T) {
+	key := newTestKey(t)
+	installer := &bundleInstaller{
+		dir:  filepath.Join(t.TempDir(), "content"),
+		keys: []ed25519.PublicKey{key.Public().(ed25519.PublicKey)},
+	}
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	current := buildTestBundle(t, key, createdAt, testBundleFiles, nil)
+	if _, err := installer.install(bytes.NewReader(current)); err != nil {
+		t.Fatalf("can't install the first bundle: %v", err)
+	}
+
+	for _, stale := range []time.Time{createdAt, createdAt.Add(-time.Hour)} {
+		bundle := buildTestBundle(t, key, stale, []testBundleFile{{"flows/old.yaml", "name: old\n"}}, nil)
+		if _, err := installer.install(bytes.NewReader(bundle)); !errors.Is(err, errStaleBundle) {
+			t.Errorf("bundle created %s: expected errStaleBundle, got %v", stale.Format(time.RFC3339), err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(installer.dir, "flows", "old.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a stale bundle changed the content directory: %v", err)
+	}
+
+	newer := buildTestBundle(t, key, createdAt.Add(time.Hour), []testBundleFile{{"flows/new.yaml", "name: new\n"}}, nil)
+	if _, err := installer.install(bytes.NewReader(newer)); err != nil {
+		t.Fatalf("can't install a newer bundle: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installer.dir, "flows", "welcome.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("files of the previous bundle are left in the content directory: %v", err)
+	}
+}
+
+func TestBundleLoadHandlerStatus(t *testing.T) {
+	key := newTestKey(t)
+	installer := &bundleInstaller{
+		dir:  filepath.Join(t.TempDir(), "content"),
+		keys: []ed25519.PublicKey{key.Public().(ed25519.PublicKey)},
+	}
+	logger, err := newReloadableLogger("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := bundleLoadHandler(installer, logger)
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := installer.install(bytes.NewReader(buildTestBundle(t, key, createdAt, testBundleFiles, nil))); err != nil {
+		t.Fatalf("can't install the first bundle: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		bundle      []byte
+		want        int
+	}{
+		{"stale bundle", buildTestBundle(t, key, createdAt, testBundleFiles, nil), http.StatusConflict},
+		{"untrusted key", buildTestBundle(t, newTestKey(t), createdAt.Add(time.Hour), testBundleFiles, nil), http.StatusUnprocessableEntity},
+		{"unsigned", buildTestBundle(t, nil, createdAt.Add(time.Hour), testBundleFiles, nil), http.StatusUnprocessableEntity},
+	}
+	for _, test := range tests {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("bundle", "bundle.tar.gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(test.bundle)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/bundle", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		if recorder.Code != test.want {
+			t.Errorf("%s: expected %d, got %d: %s", test.description, test.want, recorder.Code, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// chaosRule injects faults into requests whose path starts with
+// PathPrefix. Rates are probabilities between 0 and 1.
+type chaosRule struct {
+	PathPrefix  string  `json:"path_prefix,omitempty"`
+	Latency     string  `json:"latency,omitempty"`
+	LatencyRate float64 `json:"latency_rate,omitempty"`
+	ErrorRate   float64 `json:"error_rate,omitempty"`
+	DropRate    float64 `json:"drop_rate,omitempty"`
+
+	latency time.Duration
+}
+
+func (r *chaosRule) validate() error {
+	for _, rate := range []float64{r.LatencyRate, r.ErrorRate, r.DropRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("chaos rates must be between 0 and 1")
+		}
+	}
+	r.latency = 0
+	if r.Latency != "" {
+		latency, err := time.ParseDuration(r.Latency)
+		if err != nil {
+			return fmt.Errorf("invalid chaos latency '%s': %w", r.Latency, err)
+		}
+		r.latency = latency
+	}
+	return nil
+}
+
+// chaosMiddleware injects latency, 500s and dropped connections according
+// to the configured rules, to check that clients retry and circuit breakers
+// open. It is only installed when the server runs with --chaos.
+func chaosMiddleware(logger logging.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule := matchChaosRule(currentConfig().Chaos, r.URL.Path)
+			if rule == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if rule.latency > 0 && rand.Float64() < rule.LatencyRate {
+				logger.Debug(r.Context(), "Chaos: delaying %s %s by %s", r.Method, r.URL.Path, rule.latency)
+				select {
+				case <-time.After(rule.latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if rand.Float64() < rule.DropRate {
+				logger.Debug(r.Context(), "Chaos: dropping connection for %s %s", r.Method, r.URL.Path)
+				panic(http.ErrAbortHandler)
+			}
+			if rand.Float64() < rule.ErrorRate {
+				logger.Debug(r.Context(), "Chaos: failing %s %s", r.Method, r.URL.Path)
+				writeError(w, r, http.StatusInternalServerError, "injected failure")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchChaosRule returns the first rule whose prefix matches the path.
+func matchChaosRule(rules []chaosRule, path string) *chaosRule {
+	for i := range rules {
+		if strings.HasPrefix(path, rules[i].PathPrefix) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// callAPI sends a request to the onboarding API and decodes the data of the
+// response envelope into result, which may be nil. The payload, if any, is
+// sent as JSON and stored credentials for the server are attached.
+func callAPI(apiURL, method, path string, payload, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequest(method, apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return doAPIRequest(req, apiURL, result)
+}
+
+// doAPIRequest authorizes and sends a prepared request, decoding the
+// response envelope like callAPI. Requests for admin endpoints also carry
+// the --admin-token.
+func doAPIRequest(req *http.Request, apiURL string, result interface{}) error {
+	if err := authorize(req, apiURL); err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.TrimPrefix(req.URL.String(), apiURL), adminPathPrefix) {
+		req.Header.Set(adminTokenHeader, cliAdminToken)
+	}
+	req.Header.Set("Accept", "application/json, "+problemContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), problemContentType) {
+		return decodeProblem(resp)
+	}
+
+	var apiResp ApiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return err
+	}
+
+	if !apiResp.Success {
+		return fmt.Errorf("API error: %s", apiResp.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(apiResp.Data, result)
+}
+
+// decodeProblem turns a problem+json response into an error, keeping the
+// request ID so users can quote it when reporting the failure.
+func decodeProblem(resp *http.Response) error {
+	var problem problemDetails
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		return fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	message := problem.Title
+	if problem.Detail != "" {
+		message += ": " + problem.Detail
+	}
+	if problem.RequestID != "" {
+		message += " (request ID " + problem.RequestID + ")"
+	}
+	return fmt.Errorf("API error: %s", message)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Stages of the default onboarding flow, as reported by the API.
+const (
+	stageWelcome          = "welcome"
+	stageEnvironmentSetup = "environment_setup"
+	stageTeamIntroduction = "team_introduction"
+	stageFirstTasks       = "first_tasks"
+	stageCompletion       = "completion"
+)
+
+// chatSession is the client-side view of the session being chatted in.
+type chatSession struct {
+	ID    string
+	Stage string
+
+	// notices receives asynchronous notifications without garbling the
+	// prompt
+	notices io.Writer
+
+	mu      sync.Mutex
+	working *spinner
+}
+
+// busy runs a request with a spinner showing, which progress events from
+// the server update with what is being worked on.
+func (s *chatSession) busy(fn func() error) error {
+	progress := startSpinner("Thinking…")
+	s.mu.Lock()
+	s.working = progress
+	s.mu.Unlock()
+
+	err := fn()
+
+	s.mu.Lock()
+	s.working = nil
+	s.mu.Unlock()
+	progress.Stop()
+	return err
+}
+
+// handleEvent applies events from the session's stream.
+func (s *chatSession) handleEvent(event SessionEvent) {
+	if event.Type == "notification" {
+		showNotification(s.notices, event)
+		return
+	}
+	if event.Type == "observer" {
+		showObserver(s.notices, event)
+		return
+	}
+	if event.Type != "progress" {
+		return
+	}
+	var progress ProgressEvent
+	if err := json.Unmarshal(event.Data, &progress); err != nil || progress.Text == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.working != nil {
+		s.working.SetText(progress.Text)
+	}
+}
+
+// slashCommand is a command typed in the chat as /name [args]. Commands
+// with a Run function are handled by the CLI, the rest are sent to the
+// server.
+type slashCommand struct {
+	Name        string
+	Args        string
+	Description string
+	// Stages restricts where the command is offered, empty means everywhere
+	Stages []string
+	// Run handles the command locally and reports whether the chat should end
+	Run func(session *chatSession, args string) (bool, error)
+}
+
+func (c *slashCommand) availableIn(stage string) bool {
+	if len(c.Stages) == 0 || stage == "" {
+		return true
+	}
+	for _, s := range c.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *slashCommand) usage() string {
+	if c.Args == "" {
+		return "/" + c.Name
+	}
+	return "/" + c.Name + " " + c.Args
+}
+
+var slashCommands []*slashCommand
+
+// registerCommand adds a command to the chat. Registering a name twice
+// replaces the earlier command.
+func registerCommand(command *slashCommand) {
+	for i, existing := range slashCommands {
+		if existing.Name == command.Name {
+			slashCommands[i] = command
+			return
+		}
+	}
+	slashCommands = append(slashCommands, command)
+}
+
+func findCommand(name string) *slashCommand {
+	for _, command := range slashCommands {
+		if command.Name == name {
+			return command
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerCommand(&slashCommand{
+		Name:        "help",
+		Description: "List the commands available right now",
+		Run:         runHelpCommand,
+	})
+	registerCommand(&slashCommand{
+		Name:        "status",
+		Description: "Show onboarding progress",
+		Run: func(session *chatSession, args string) (bool, error) {
+			return false, showStatus(apiURL, session.ID)
+		},
+	})
+	registerCommand(&slashCommand{
+		Name:        "timeline",
+		Description: "Show what has happened in this session so far",
+		Run: func(session *chatSession, args string) (bool, error) {
+			return false, showTimeline(os.Stdout, apiURL, session.ID)
+		},
+	})
+	registerCommand(&slashCommand{
+		Name:        "attach",
+		Args:        "<path>",
+		Description: "Attach an error log or screenshot for your mentor",
+		Run: func(session *chatSession, args string) (bool, error) {
+			return false, attachFile(apiURL, session.ID, args)
+		},
+	})
+	registerCommand(&slashCommand{
+		Name:        "notifications",
+		Args:        "[name on|off]",
+		Description: "Choose which channels and events you are notified about",
+		Run:         runNotificationsCommand,
+	})
+	registerCommand(&slashCommand{
+		Name:        "snooze",
+		Args:        "<duration>|off",
+		Description: "Hold back notifications for a while, e.g. during a meeting",
+		Run:         runSnoozeCommand,
+	})
+	registerCommand(&slashCommand{
+		Name:        "handover",
+		Description: "Continue this session on another device or in the web UI",
+		Run: func(session *chatSession, args string) (bool, error) {
+			return false, showHandover(os.Stdout, apiURL, session.ID)
+		},
+	})
+	registerCommand(&slashCommand{
+		Name:        "quit",
+		Description: "Leave the chat, the session can be resumed later",
+		Run: func(session *chatSession, args string) (bool, error) {
+			fmt.Println("Goodbye! You can resume your onboarding session later.")
+			return true, nil
+		},
+	})
+
+	// Handled by the server
+	registerCommand(&slashCommand{
+		Name:        "skip",
+		Description: "Skip the current task",
+		Stages:      []string{stageWelcome, stageEnvironmentSetup, stageTeamIntroduction, stageFirstTasks},
+	})
+	registerCommand(&slashCommand{
+		Name:        "back",
+		Description: "Go back to the previous task",
+		Stages:      []string{stageEnvironmentSetup, stageTeamIntroduction, stageFirstTasks, stageCompletion},
+	})
+	registerCommand(&slashCommand{
+		Name:        "checklist",
+		Description: "Show the checklist for the current stage",
+	})
+	registerCommand(&slashCommand{
+		Name:        "remind",
+		Args:        "me in <duration>",
+		Description: "Get a reminder about the current task later",
+	})
+	registerCommand(&slashCommand{
+		Name:        "share",
+		Description: "Create a read-only observer token for a mentor or trainer",
+	})
+	registerCommand(&slashCommand{
+		Name:        "escalate",
+		Args:        "[reason]",
+		Description: "Ask a human mentor for help",
+	})
+}
+
+// runSlashCommand dispatches a chat line starting with '/' and reports
+// whether the chat should end.
+func runSlashCommand(session *chatSession, line string) bool {
+	name, args, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	if name == "exit" {
+		name = "quit"
+	}
+	args = strings.TrimSpace(args)
+
+	command := findCommand(name)
+	if command == nil {
+		fmt.Printf("Unknown command '/%s', type /help for the list\n", name)
+		return false
+	}
+	if !command.availableIn(session.Stage) {
+		fmt.Printf("/%s isn't available during the %s stage\n", name, session.Stage)
+		return false
+	}
+
+	if command.Run != nil {
+		quit, err := command.Run(session, args)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+		return quit
+	}
+
+	var response *MessageResponse
+	err := session.busy(func() (err error) {
+		response, err = sendCommand(apiURL, session.ID, name, args)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+	printMessageResponse(session, response)
+	return false
+}
+
+func runHelpCommand(session *chatSession, args string) (bool, error) {
+	fmt.Println("\nAvailable commands:")
+	for _, command := range slashCommands {
+		if command.availableIn(session.Stage) {
+			fmt.Printf("  %-30s %s\n", command.usage(), command.Description)
+		}
+	}
+	fmt.Println()
+	return false, nil
+}
+
+// sendCommand asks the server to run a slash command it handles.
+func sendCommand(apiURL, sessionID, command, args string) (*MessageResponse, error) {
+	payload := map[string]string{
+		"session_id": sessionID,
+		"command":    command,
+		"args":       args,
+	}
+
+	var msgResp MessageResponse
+	if err := callAPI(apiURL, http.MethodPost, "/api/v1/onboarding/command", payload, &msgResp); err != nil {
+		return nil, err
+	}
+
+	return &msgResp, nil
+}
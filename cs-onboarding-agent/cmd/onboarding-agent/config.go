@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift-online/ocm-cluster-service/pkg/featureflag"
+)
+
+// serverConfig holds the server settings. They start from the command line
+// flags and are overridden by whatever the --config file sets. The file is
+// re-read on SIGHUP or through the admin reload endpoint; everything except
+// the OCM connection and air-gapped settings takes effect immediately.
+type serverConfig struct {
+	LogLevel       string `json:"log_level,omitempty"`
+	MaxBodyBytes   int64  `json:"max_body_bytes,omitempty"`
+	MaxUploadBytes int64  `json:"max_upload_bytes,omitempty"`
+	// MaxMessageChars bounds the chat messages stored with a session,
+	// which need far less than MaxBodyBytes
+	MaxMessageChars       int       `json:"max_message_chars,omitempty"`
+	AttachmentScanCommand string    `json:"attachment_scan_command,omitempty"`
+	OCM                   ocmConfig `json:"ocm,omitempty"`
+	// Chaos rules only apply when the server runs with --chaos
+	Chaos       []chaosRule       `json:"chaos,omitempty"`
+	Maintenance maintenanceConfig `json:"maintenance,omitempty"`
+	Admission   admissionConfig   `json:"admission,omitempty"`
+	AirGapped   airGappedConfig   `json:"air_gapped,omitempty"`
+}
+
+var activeConfig atomic.Pointer[serverConfig]
+
+// features holds the feature flags loaded from --feature-flags, replaced
+// on every reload.
+var features atomic.Pointer[featureflag.Set]
+
+// currentConfig returns the configuration in effect. Callers must not
+// modify it.
+func currentConfig() *serverConfig {
+	return activeConfig.Load()
+}
+
+func loadServerConfig() (*serverConfig, error) {
+	config := &serverConfig{
+		LogLevel:              logLevel,
+		MaxBodyBytes:          maxBodyBytes,
+		MaxUploadBytes:        maxUploadBytes,
+		MaxMessageChars:       maxMessageChars,
+		AttachmentScanCommand: attachmentScanCmd,
+		OCM:                   ocmFlags,
+		Admission:             admissionFlags,
+		AirGapped:             airGappedFlags,
+	}
+	if chaosFlags.LatencyRate > 0 || chaosFlags.ErrorRate > 0 || chaosFlags.DropRate > 0 {
+		config.Chaos = []chaosRule{chaosFlags}
+	}
+
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(data, config); err != nil {
+			return nil, fmt.Errorf("can't parse '%s': %w", configFile, err)
+		}
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *serverConfig) validate() error {
+	if _, ok := logLevels[c.LogLevel]; !ok {
+		return fmt.Errorf("unknown log level '%s'", c.LogLevel)
+	}
+	if c.MaxBodyBytes <= 0 || c.MaxUploadBytes <= 0 || c.MaxMessageChars <= 0 {
+		return fmt.Errorf("body and message limits must be positive")
+	}
+	if err := c.Admission.validate(); err != nil {
+		return err
+	}
+	if err := c.AirGapped.validate(c.OCM); err != nil {
+		return err
+	}
+	for i := range c.Chaos {
+		if err := c.Chaos[i].validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diff lists the settings that differ between two configurations, as
+// 'name: old -> new'.
+func (c *serverConfig) diff(other *serverConfig) []string {
+	changes := []string{}
+	before, after := reflect.ValueOf(*c), reflect.ValueOf(*other)
+	for i := 0; i < before.NumField(); i++ {
+		if reflect.DeepEqual(before.Field(i).Interface(), after.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(before.Type().Field(i).Tag.Get("json"), ",")
+		changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, before.Field(i).Interface(), after.Field(i).Interface()))
+	}
+	return changes
+}
+
+// reloadConfig re-reads the configuration and applies it, logging every
+// setting that changed and recording them in the audit log under actor. An
+// invalid file leaves the running configuration untouched.
+func reloadConfig(ctx context.Context, logger *reloadableLogger, actor string) ([]string, error) {
+	config, err := loadServerConfig()
+	if err != nil {
+		logger.Error(ctx, "Configuration reload failed: %v", err)
+		return nil, err
+	}
+
+	flags, err := featureflag.Load(featureFlagsFile)
+	if err != nil {
+		logger.Error(ctx, "Configuration reload failed: %v", err)
+		return nil, err
+	}
+
+	// The OCM connection and the egress guard are built once at startup
+	previous := currentConfig()
+	if !reflect.DeepEqual(previous.OCM, config.OCM) {
+		logger.Warn(ctx, "OCM connection settings changed, restart the server to apply them")
+		config.OCM = previous.OCM
+	}
+	if !reflect.DeepEqual(previous.AirGapped, config.AirGapped) {
+		logger.Warn(ctx, "Air-gapped settings changed, restart the server to apply them")
+		config.AirGapped = previous.AirGapped
+	}
+
+	changes := previous.diff(config)
+	if err := logger.SetLevel(config.LogLevel); err != nil {
+		return nil, err
+	}
+	activeConfig.Store(config)
+	features.Store(flags)
+
+	// Only a change in the file touches maintenance mode, so reloading
+	// doesn't undo a toggle made through the admin endpoint
+	if !reflect.DeepEqual(previous.Maintenance, config.Maintenance) {
+		maintenance.Set(config.Maintenance)
+	}
+
+	err = audit.record(auditEntry{
+		Action:    "config.reload",
+		Actor:     actor,
+		RequestID: requestID(ctx),
+		Details:   map[string]interface{}{"changes": changes},
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to write audit log: %v", err)
+	}
+
+	if len(changes) == 0 {
+		logger.Info(ctx, "Configuration reloaded, nothing changed")
+	}
+	for _, change := range changes {
+		logger.Info(ctx, "Configuration reloaded: %s", change)
+	}
+	return changes, nil
+}
+
+// configReloadHandler lets operators trigger a reload over HTTP where
+// sending a signal to the process isn't practical.
+func configReloadHandler(logger *reloadableLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changes, err := reloadConfig(r.Context(), logger, r.RemoteAddr)
+		if err != nil {
+			writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		writeData(w, r, http.StatusOK, map[string]interface{}{"changes": changes})
+	}
+}
+
+// featuresHandler lists the feature flags currently loaded.
+func featuresHandler(w http.ResponseWriter, r *http.Request) {
+	writeData(w, r, http.StatusOK, map[string]interface{}{"flags": features.Load().Flags()})
+}
+
+func envOrDefault(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+func enableVirtualTerminal() {}
+
+func terminalSupportsEmoji() bool {
+	return true
+}
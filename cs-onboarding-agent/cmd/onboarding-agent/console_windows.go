@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal turns on ANSI escape handling in the Windows
+// console, which the spinner and the markdown renderer rely on. Consoles
+// that don't support it are left as they are.
+func enableVirtualTerminal() {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(f.Fd())
+		var mode uint32
+		if err := windows.GetConsoleMode(handle, &mode); err != nil {
+			continue
+		}
+		_ = windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	}
+}
+
+// terminalSupportsEmoji reports whether the console can draw emoji. The
+// classic console host shows them as boxes; Windows Terminal, the VS Code
+// terminal and ConEmu draw them fine.
+func terminalSupportsEmoji() bool {
+	return os.Getenv("WT_SESSION") != "" || os.Getenv("TERM_PROGRAM") == "vscode" || os.Getenv("ConEmuANSI") == "ON"
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Dependency is something outside the user's control that a session waits
+// on: a ticket, an invite or a provisioned resource.
+type Dependency struct {
+	Kind  string    `json:"kind"` // ticket, invite or resource
+	Name  string    `json:"name"`
+	State string    `json:"state"`
+	Owner string    `json:"owner,omitempty"`
+	Since time.Time `json:"since"`
+	// Done is set once the dependency no longer blocks anything
+	Done bool   `json:"done"`
+	URL  string `json:"url,omitempty"`
+}
+
+// showDependencies prints the session's dependency board, open items
+// first, so "what am I waiting on?" is answered at a glance.
+func showDependencies(out io.Writer, apiURL, sessionID string) error {
+	var dependencies []Dependency
+	path := "/api/v1/onboarding/sessions/" + url.PathEscape(sessionID) + "/dependencies"
+	if err := callAPI(apiURL, http.MethodGet, path, nil, &dependencies); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "\nWaiting on:")
+	if len(dependencies) == 0 {
+		fmt.Fprintln(out, "  Nothing, no tickets, invites or resources are pending")
+		return nil
+	}
+
+	now := time.Now()
+	fmt.Fprintf(out, "     %-9s %-36s %-14s %-20s %s\n", "KIND", "NAME", "STATE", "OWNER", "AGE")
+	for _, done := range []bool{false, true} {
+		for _, dependency := range dependencies {
+			if dependency.Done != done {
+				continue
+			}
+			mark := symbols().Waiting
+			if dependency.Done {
+				mark = symbols().Done
+			}
+			owner := dependency.Owner
+			if owner == "" {
+				owner = "-"
+			}
+			fmt.Fprintf(out, "  %s %-9s %-36s %-14s %-20s %s\n",
+				mark, dependency.Kind, dependency.Name, dependency.State, owner, humanizeDuration(now.Sub(dependency.Since)))
+			if dependency.URL != "" && !dependency.Done {
+				fmt.Fprintf(out, "     %s\n", dependency.URL)
+			}
+		}
+	}
+	return nil
+}
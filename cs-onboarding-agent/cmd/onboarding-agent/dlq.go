@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// DeadLetter is an outbound effect (webhook, service log, email) that kept
+// failing after all retries.
+type DeadLetter struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	SessionID string          `json:"session_id"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	FailedAt  time.Time       `json:"failed_at"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+var dlqType string
+
+// newDLQCommand builds the 'dlq' group for inspecting and replaying failed
+// outbound effects.
+func newDLQCommand() *cobra.Command {
+	dlqCmd := &cobra.Command{
+		Use:   "dlq",
+		Short: "Inspect and replay failed outbound effects (admin)",
+	}
+	dlqCmd.PersistentFlags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	addAdminTokenFlag(dlqCmd.PersistentFlags())
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List dead-lettered effects",
+		Args:  cobra.NoArgs,
+		Run:   runDLQList,
+	}
+	listCmd.Flags().StringVar(&dlqType, "type", "", "Only show effects of this type, e.g. webhook, servicelog or email")
+
+	showCmd := &cobra.Command{
+		Use:   "show [id]",
+		Short: "Show a dead-lettered effect including its payload",
+		Args:  cobra.ExactArgs(1),
+		Run:   runDLQShow,
+	}
+
+	retryCmd := &cobra.Command{
+		Use:   "retry [id...]",
+		Short: "Queue effects for delivery again",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runDLQAction(args, http.MethodPost, "/retry", "Queued for retry")
+		},
+	}
+
+	discardCmd := &cobra.Command{
+		Use:   "discard [id...]",
+		Short: "Drop effects without delivering them",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runDLQAction(args, http.MethodDelete, "", "Discarded")
+		},
+	}
+
+	dlqCmd.AddCommand(listCmd, showCmd, retryCmd, discardCmd)
+	return dlqCmd
+}
+
+func runDLQList(cmd *cobra.Command, args []string) {
+	path := "/api/v1/admin/dlq"
+	if dlqType != "" {
+		path += "?type=" + url.QueryEscape(dlqType)
+	}
+
+	var letters []DeadLetter
+	if err := callAPI(apiURL, http.MethodGet, path, nil, &letters); err != nil {
+		fmt.Printf("Failed to list dead letters: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(letters) == 0 {
+		fmt.Println("No dead letters")
+		return
+	}
+	fmt.Printf("%-24s %-12s %-24s %-8s %-20s %s\n", "ID", "TYPE", "SESSION", "ATTEMPTS", "FAILED", "LAST ERROR")
+	for _, letter := range letters {
+		fmt.Printf("%-24s %-12s %-24s %-8d %-20s %s\n",
+			letter.ID, letter.Type, letter.SessionID, letter.Attempts,
+			letter.FailedAt.Local().Format("2006-01-02 15:04:05"), letter.LastError)
+	}
+}
+
+func runDLQShow(cmd *cobra.Command, args []string) {
+	var letter DeadLetter
+	if err := callAPI(apiURL, http.MethodGet, "/api/v1/admin/dlq/"+url.PathEscape(args[0]), nil, &letter); err != nil {
+		fmt.Printf("Failed to get dead letter: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("ID:         %s\n", letter.ID)
+	fmt.Printf("Type:       %s\n", letter.Type)
+	fmt.Printf("Session:    %s\n", letter.SessionID)
+	fmt.Printf("Attempts:   %d\n", letter.Attempts)
+	fmt.Printf("Failed at:  %s\n", letter.FailedAt.Local().Format(time.RFC1123))
+	fmt.Printf("Last error: %s\n", letter.LastError)
+	fmt.Println("Payload:")
+	pretty, err := json.MarshalIndent(letter.Payload, "  ", "  ")
+	if err != nil {
+		pretty = letter.Payload
+	}
+	fmt.Printf("  %s\n", pretty)
+}
+
+// runDLQAction applies the same action to each ID, reporting every failure
+// before exiting with an error.
+func runDLQAction(ids []string, method, suffix, done string) {
+	failed := false
+	for _, id := range ids {
+		err := callAPI(apiURL, method, "/api/v1/admin/dlq/"+url.PathEscape(id)+suffix, nil, nil)
+		if err != nil {
+			fmt.Printf("  %s %s: %v\n", symbols().Failed, id, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("  %s %s: %s\n", symbols().OK, id, done)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
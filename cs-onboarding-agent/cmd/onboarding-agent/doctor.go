@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// endpoint is an internal service the onboarding stages expect to reach
+// from the user's machine.
+type endpoint struct {
+	Name string
+	URL  string
+
+	// Authenticated endpoints are called with the stored login, so a 401 or
+	// 403 means the credentials are wrong rather than that sign-in is needed.
+	Authenticated bool
+}
+
+var defaultEndpoints = []endpoint{
+	{Name: "Git", URL: "https://gitlab.cee.redhat.com"},
+	{Name: "JIRA", URL: "https://issues.redhat.com"},
+	{Name: "OCM gateway", URL: "https://api.openshift.com"},
+	{Name: "Registry", URL: "https://quay.io/v2/"},
+}
+
+// tool is a command-line tool the bootstrap script installs, checked by
+// running it with arguments that print its version.
+type tool struct {
+	Name        string
+	VersionArgs []string
+}
+
+var defaultTools = []tool{
+	{Name: "git", VersionArgs: []string{"--version"}},
+	{Name: "go", VersionArgs: []string{"version"}},
+	{Name: "ocm", VersionArgs: []string{"version"}},
+	{Name: "oc", VersionArgs: []string{"version", "--client"}},
+	{Name: "podman", VersionArgs: []string{"--version"}},
+	{Name: "jq", VersionArgs: []string{"--version"}},
+}
+
+// Failure phases, in the order a request goes through them.
+const (
+	phaseDNS     = "dns"
+	phaseConnect = "connect"
+	phaseTLS     = "tls"
+	phaseAuth    = "auth"
+	phaseHTTP    = "http"
+)
+
+var phaseGuidance = map[string]string{
+	phaseDNS:     "The name does not resolve from this machine. Internal hosts only resolve on the VPN: connect to it and check that its DNS servers are in use.",
+	phaseConnect: "The name resolves but nothing answers. Check the VPN is connected, and that no firewall or missing HTTPS_PROXY is blocking the port.",
+	phaseTLS:     "The connection works but the certificate is not trusted. Install the corporate root CA into the system trust store.",
+	phaseAuth:    "The service answered but rejected the request as unauthenticated. Run 'onboarding-agent login', or log in again if the stored login has expired.",
+	phaseHTTP:    "The service is reachable but returned an error. It may be down; try again later or ask in the team channel.",
+}
+
+// windowsPhaseGuidance replaces the advice that differs on Windows, where
+// the proxy is set in PowerShell and certificates live in the Windows
+// certificate store.
+var windowsPhaseGuidance = map[string]string{
+	phaseConnect: "The name resolves but nothing answers. Check the VPN is connected, and that no firewall is blocking the port. Behind a proxy, set it for this PowerShell session with $env:HTTPS_PROXY = \"http://proxy:port\".",
+	phaseTLS:     "The connection works but the certificate is not trusted. Import the corporate root CA into 'Trusted Root Certification Authorities' with certmgr.msc, or in PowerShell: Import-Certificate -FilePath ca.crt -CertStoreLocation Cert:\\CurrentUser\\Root",
+}
+
+func guidanceFor(phase string) string {
+	if runtime.GOOS == "windows" {
+		if guidance, ok := windowsPhaseGuidance[phase]; ok {
+			return guidance
+		}
+	}
+	return phaseGuidance[phase]
+}
+
+type probeResult struct {
+	Phase    string // empty on success
+	Err      error
+	Status   int
+	Duration time.Duration
+}
+
+var (
+	doctorEndpoints []string
+	doctorTools     []string
+	doctorTimeout   time.Duration
+)
+
+// newDoctorCommand builds the 'doctor' command that checks the user's
+// machine can reach everything the onboarding stages rely on.
+func newDoctorCommand() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that required tools are installed and internal services are reachable",
+		Args:  cobra.NoArgs,
+		Run:   runDoctor,
+	}
+	doctorCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	doctorCmd.Flags().StringArrayVar(&doctorEndpoints, "endpoint", nil, "Check 'name=url' instead of the default endpoints (repeatable)")
+	doctorCmd.Flags().StringSliceVar(&doctorTools, "tools", nil, "Check these tools instead of the default ones, e.g. git,ocm,oc")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 5*time.Second, "Timeout for each step of a check")
+	return doctorCmd
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+	endpoints, err := doctorEndpointList()
+	if err != nil {
+		fmt.Printf("Invalid endpoint: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := !checkTools(doctorToolList())
+
+	fmt.Println("\nChecking network reachability:")
+	for _, e := range endpoints {
+		result := probeEndpoint(e)
+		if result.Phase == "" {
+			fmt.Printf("  %s %-14s %s (HTTP %d, %s)\n", symbols().OK, e.Name, e.URL, result.Status, result.Duration.Round(time.Millisecond))
+			continue
+		}
+		failed = true
+		fmt.Printf("  %s %-14s %s\n", symbols().Failed, e.Name, e.URL)
+		fmt.Printf("      %s failed: %v\n", strings.ToUpper(result.Phase), result.Err)
+		fmt.Printf("      %s %s\n", symbols().Hint, guidanceFor(result.Phase))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// doctorToolList returns the tools named with --tools, looking up the
+// version arguments of known ones, or the defaults.
+func doctorToolList() []tool {
+	if len(doctorTools) == 0 {
+		return defaultTools
+	}
+
+	tools := make([]tool, 0, len(doctorTools))
+	for _, name := range doctorTools {
+		t := tool{Name: name, VersionArgs: []string{"--version"}}
+		for _, known := range defaultTools {
+			if known.Name == name {
+				t = known
+			}
+		}
+		tools = append(tools, t)
+	}
+	return tools
+}
+
+// checkTools reports each tool's location and version, returning false if
+// any is missing or fails to run.
+func checkTools(tools []tool) bool {
+	fmt.Println("Checking tools:")
+	ok := true
+	for _, t := range tools {
+		path, err := exec.LookPath(t.Name)
+		if err != nil {
+			ok = false
+			fmt.Printf("  %s %-14s not found in PATH\n", symbols().Failed, t.Name)
+			fmt.Printf("      %s Run the bootstrap script from 'onboarding-agent artifacts list', or install it by hand.\n", symbols().Hint)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		output, err := exec.CommandContext(ctx, path, t.VersionArgs...).CombinedOutput()
+		cancel()
+		if err != nil {
+			ok = false
+			fmt.Printf("  %s %-14s %s\n", symbols().Failed, t.Name, path)
+			fmt.Printf("      '%s %s' failed: %v\n", t.Name, strings.Join(t.VersionArgs, " "), err)
+			continue
+		}
+		version, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+		fmt.Printf("  %s %-14s %s\n", symbols().OK, t.Name, version)
+	}
+	return ok
+}
+
+// doctorEndpointList returns the endpoints to check: the onboarding API
+// itself followed by either the defaults or those given with --endpoint.
+func doctorEndpointList() ([]endpoint, error) {
+	endpoints := []endpoint{{
+		Name:          "Onboarding API",
+		URL:           strings.TrimSuffix(apiURL, "/") + "/api/v1/onboarding/health",
+		Authenticated: true,
+	}}
+	if len(doctorEndpoints) == 0 {
+		return append(endpoints, defaultEndpoints...), nil
+	}
+
+	for _, value := range doctorEndpoints {
+		name, rawURL, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("'%s' is not in the form name=url", value)
+		}
+		if _, err := url.Parse(rawURL); err != nil {
+			return nil, fmt.Errorf("'%s': %w", value, err)
+		}
+		endpoints = append(endpoints, endpoint{Name: name, URL: rawURL})
+	}
+	return endpoints, nil
+}
+
+// probeEndpoint walks through DNS, TCP and TLS separately before sending
+// the request, so a failure can be pinned to the step that broke. When a
+// proxy is configured the direct steps are skipped, since only the proxy
+// needs to be reachable.
+func probeEndpoint(e endpoint) probeResult {
+	start := time.Now()
+	target, err := url.Parse(e.URL)
+	if err != nil {
+		return probeResult{Phase: phaseHTTP, Err: err}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, e.URL, nil)
+	if err != nil {
+		return probeResult{Phase: phaseHTTP, Err: err}
+	}
+
+	if proxy, _ := http.ProxyFromEnvironment(req); proxy == nil {
+		if result, ok := probeDirect(target); !ok {
+			return result
+		}
+	}
+
+	if e.Authenticated {
+		if err := authorize(req, apiURL); err != nil {
+			return probeResult{Phase: phaseAuth, Err: err}
+		}
+	}
+
+	client := &http.Client{
+		Timeout: doctorTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return probeResult{Phase: classifyError(err), Err: err}
+	}
+	resp.Body.Close()
+
+	result := probeResult{Status: resp.StatusCode, Duration: time.Since(start)}
+	switch {
+	case e.Authenticated && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden):
+		result.Phase, result.Err = phaseAuth, errors.New(resp.Status)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		result.Phase, result.Err = phaseHTTP, errors.New(resp.Status)
+	}
+	return result
+}
+
+func probeDirect(target *url.URL) (probeResult, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	host := target.Hostname()
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		return probeResult{Phase: phaseDNS, Err: err}, false
+	}
+
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+	dialer := &net.Dialer{Timeout: doctorTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return probeResult{Phase: phaseConnect, Err: err}, false
+	}
+	defer conn.Close()
+
+	if target.Scheme != "https" {
+		return probeResult{}, true
+	}
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return probeResult{Phase: phaseTLS, Err: err}, false
+	}
+	return probeResult{}, true
+}
+
+// classifyError maps an error from the HTTP client to the phase it came
+// from, for the cases where the direct probe was skipped or passed.
+func classifyError(err error) string {
+	var dnsErr *net.DNSError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certErr *tls.CertificateVerificationError
+	switch {
+	case errors.As(err, &dnsErr):
+		return phaseDNS
+	case errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr), errors.As(err, &certErr):
+		return phaseTLS
+	default:
+		return phaseConnect
+	}
+}
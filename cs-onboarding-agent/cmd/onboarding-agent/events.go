@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SessionEvent is a server-sent event from a session's event stream.
+type SessionEvent struct {
+	Type string
+	Data json.RawMessage
+}
+
+// ProgressEvent reports what the server is busy with while it works on a
+// message, e.g. running a verification or waiting for the LLM.
+type ProgressEvent struct {
+	Text string `json:"text"`
+}
+
+// ObserverEvent announces that someone started or stopped watching the
+// session read-only, so the user is never observed without knowing.
+type ObserverEvent struct {
+	Name   string `json:"name"`
+	Joined bool   `json:"joined"`
+}
+
+// streamEvents follows the session's event stream until ctx is cancelled
+// or the server closes it, calling handle for every event.
+func streamEvents(ctx context.Context, apiURL, sessionID string, handle func(SessionEvent)) error {
+	return streamEventsFrom(ctx, apiURL, "/api/v1/onboarding/events/"+sessionID, handle)
+}
+
+// streamEventsFrom reads an event stream from path, which may carry a
+// query such as an observer token.
+func streamEventsFrom(ctx context.Context, apiURL, path string, handle func(SessionEvent)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if err := authorize(req, apiURL); err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("event stream returned %s", resp.Status)
+	}
+
+	event := SessionEvent{Type: "message"}
+	var data []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				event.Data = json.RawMessage(strings.Join(data, "\n"))
+				handle(event)
+			}
+			event = SessionEvent{Type: "message"}
+			data = nil
+		case strings.HasPrefix(line, ":"):
+			// Keep-alive comment
+		case strings.HasPrefix(line, "event:"):
+			event.Type = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/mdp/qrterminal/v3"
+	"github.com/spf13/cobra"
+)
+
+// HandoverCode is a short-lived code that lets the same user continue a
+// session on another device. URL opens the session in the web UI.
+type HandoverCode struct {
+	Code      string    `json:"code"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HandoverSession is what redeeming a code returns: the session and the
+// identity it belongs to.
+type HandoverSession struct {
+	SessionID string `json:"session_id"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+}
+
+// newHandoverCommand builds the 'handover' command that moves a session to
+// another device.
+func newHandoverCommand() *cobra.Command {
+	handoverCmd := &cobra.Command{
+		Use:   "handover [session-id]",
+		Short: "Show a code and QR code for continuing a session on another device",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runHandover,
+	}
+	handoverCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	return handoverCmd
+}
+
+// newResumeCommand builds the 'resume' command that redeems a handover
+// code on the new device.
+func newResumeCommand() *cobra.Command {
+	resumeCmd := &cobra.Command{
+		Use:   "resume [code]",
+		Short: "Continue the chat of a session handed over from another device",
+		Args:  cobra.ExactArgs(1),
+		Run:   runResume,
+	}
+	resumeCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	resumeCmd.Flags().BoolVar(&desktopNotify, "notify", false, "Raise desktop notifications for mentor replies, ticket updates and nudges")
+
+	return resumeCmd
+}
+
+func runHandover(cmd *cobra.Command, args []string) {
+	sessionID := ""
+	if len(args) > 0 {
+		sessionID = args[0]
+	} else if profile, err := loadProfile(); err == nil {
+		sessionID = profile.SessionID
+	}
+	if sessionID == "" {
+		fmt.Println("Please provide a session ID, no recent session is known")
+		os.Exit(1)
+	}
+
+	if err := showHandover(os.Stdout, apiURL, sessionID); err != nil {
+		fmt.Printf("Failed to create handover code: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// showHandover asks the server for a handover code and prints it with a
+// QR code of the web UI link, for scanning from a phone or tablet.
+func showHandover(out io.Writer, apiURL, sessionID string) error {
+	var handover HandoverCode
+	path := "/api/v1/onboarding/sessions/" + url.PathEscape(sessionID) + "/handover"
+	if err := callAPI(apiURL, http.MethodPost, path, nil, &handover); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\nContinue this session on another device within %s:\n\n", humanizeDuration(time.Until(handover.ExpiresAt)))
+	if handover.URL != "" && !accessible {
+		qrterminal.GenerateWithConfig(handover.URL, qrterminal.Config{
+			Level:          qrterminal.L,
+			Writer:         out,
+			HalfBlocks:     true,
+			BlackChar:      qrterminal.BLACK_BLACK,
+			WhiteBlackChar: qrterminal.WHITE_BLACK,
+			WhiteChar:      qrterminal.WHITE_WHITE,
+			BlackWhiteChar: qrterminal.BLACK_WHITE,
+			QuietZone:      2,
+		})
+	}
+	switch {
+	case handover.URL != "" && accessible:
+		fmt.Fprintf(out, "  Web UI:  open %s\n", handover.URL)
+	case handover.URL != "":
+		fmt.Fprintf(out, "  Web UI:  scan the code or open %s\n", handover.URL)
+	}
+	fmt.Fprintf(out, "  CLI:     onboarding-agent resume %s --api-url %s\n\n", handover.Code, apiURL)
+	fmt.Fprintln(out, "The code works once. Anyone holding it can continue your session, so don't share it.")
+	return nil
+}
+
+func runResume(cmd *cobra.Command, args []string) {
+	var session HandoverSession
+	payload := map[string]string{"code": args[0]}
+	if err := callAPI(apiURL, http.MethodPost, "/api/v1/onboarding/handover/redeem", payload, &session); err != nil {
+		fmt.Printf("Failed to redeem handover code: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Adopt the identity and session so the other commands default to it
+	profile, err := loadProfile()
+	if err != nil {
+		fmt.Printf("Failed to load profile: %v\n", err)
+		os.Exit(1)
+	}
+	profile.UserID = session.UserID
+	profile.Username = session.Username
+	profile.Email = session.Email
+	profile.APIURL = apiURL
+	profile.SessionID = session.SessionID
+	if err := saveProfile(profile); err != nil {
+		fmt.Printf("Failed to save profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Session %s for %s is now on this device.\n\n", session.SessionID, session.Username)
+
+	// Continue the chat where the other device left off, rather than
+	// starting a new session
+	var current MessageResponse
+	if err := callAPI(apiURL, http.MethodGet, "/api/v1/onboarding/status/"+session.SessionID, nil, &current); err != nil {
+		fmt.Printf("Failed to get session status: %v\n", err)
+		os.Exit(1)
+	}
+	in, err := newInput()
+	if err != nil {
+		fmt.Printf("Failed to initialize terminal input: %v\n", err)
+		os.Exit(1)
+	}
+	defer in.Close()
+
+	fmt.Printf("Agent: %s\n", renderMarkdown(current.Message))
+	chat(in, &chatSession{ID: session.SessionID, Stage: current.Stage, notices: in.Stdout()})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// humanizeDuration renders a duration the way a person would say it,
+// rounded to its largest unit: "5 minutes", "3 hours", "2 days". Phrases
+// go through the message catalog in i18n.go.
+func humanizeDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+
+	switch {
+	case d < time.Minute:
+		return tr("less than a minute")
+	case d < time.Hour:
+		return trn(int(d/time.Minute), "%d minute", "%d minutes")
+	case d < 24*time.Hour:
+		return trn(int(d/time.Hour), "%d hour", "%d hours")
+	case d < 14*24*time.Hour:
+		return trn(int(d/(24*time.Hour)), "%d day", "%d days")
+	default:
+		return trn(int(d/(7*24*time.Hour)), "%d week", "%d weeks")
+	}
+}
+
+// humanizeFromNow describes a time relative to now: "in 3 days" or
+// "5 minutes ago".
+func humanizeFromNow(t, now time.Time) string {
+	if t.After(now) {
+		return tr("in %s", humanizeDuration(t.Sub(now)))
+	}
+	return tr("%s ago", humanizeDuration(now.Sub(t)))
+}
+
+// humanizeDue describes a deadline relative to now, in the local timezone:
+// "due today at 15:00", "due tomorrow", "due Friday", "overdue by 2 days".
+func humanizeDue(due, now time.Time) string {
+	due, now = due.In(time.Local), now.In(time.Local)
+	if due.Before(now) {
+		return tr("overdue by %s", humanizeDuration(now.Sub(due)))
+	}
+
+	switch days := calendarDays(now, due); {
+	case days == 0:
+		return tr("due today at %s", due.Format("15:04"))
+	case days == 1:
+		return tr("due tomorrow")
+	default:
+		return tr("due %s", humanizeDay(due, now))
+	}
+}
+
+// humanizeETA describes an estimated completion time: "about 3 days left,
+// done around Friday".
+func humanizeETA(eta, now time.Time) string {
+	eta, now = eta.In(time.Local), now.In(time.Local)
+	if !eta.After(now) {
+		return tr("should be finishing any time now")
+	}
+	// "about less than a minute left" would read oddly
+	if eta.Sub(now) < time.Minute {
+		return tr("less than a minute left")
+	}
+
+	left := humanizeDuration(eta.Sub(now))
+	switch days := calendarDays(now, eta); {
+	case days == 0:
+		return tr("about %s left, done today", left)
+	case days == 1:
+		return tr("about %s left, done tomorrow", left)
+	default:
+		return tr("about %s left, done around %s", left, humanizeDay(eta, now))
+	}
+}
+
+// humanizeDay names a day after now: its weekday within the week, else its
+// date, with the year only if it isn't this year's.
+func humanizeDay(t, now time.Time) string {
+	switch {
+	case calendarDays(now, t) < 7:
+		return tr(t.Weekday().String())
+	case t.Year() == now.Year():
+		return shortDate(t)
+	default:
+		return tr("%[1]s %[2]d, %[3]d", tr(t.Month().String()[:3]), t.Day(), t.Year())
+	}
+}
+
+// shortDate renders a date like "Mon, Jan 2" in the user's language.
+func shortDate(t time.Time) string {
+	return tr("%[1]s, %[2]s %[3]d", tr(t.Weekday().String()[:3]), tr(t.Month().String()[:3]), t.Day())
+}
+
+// calendarDays counts the midnights between two times, so 23:00 to 01:00
+// is one day even though only two hours pass.
+func calendarDays(from, to time.Time) int {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	start := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start) / (24 * time.Hour))
+}
+
+// formatTimestamp shows an absolute time in the local timezone alongside
+// its relative form.
+func formatTimestamp(t time.Time, now time.Time) string {
+	local := t.In(time.Local)
+	return fmt.Sprintf("%s %s (%s)", shortDate(local), local.Format("15:04 MST"), humanizeFromNow(t, now))
+}
+
+// formatSize renders a byte count with a binary unit: "512 B", "3.2 KiB".
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func setLanguage(t *testing.T, lang string) {
+	t.Helper()
+	previous := language
+	language = lang
+	t.Cleanup(func() { language = previous })
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	setLanguage(t, "en")
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "less than a minute"},
+		{59 * time.Second, "less than a minute"},
+		{time.Minute, "1 minute"},
+		{-5 * time.Minute, "5 minutes"},
+		{90 * time.Minute, "1 hour"},
+		{36 * time.Hour, "1 day"},
+		{13 * 24 * time.Hour, "13 days"},
+		{20 * 24 * time.Hour, "2 weeks"},
+	}
+	for _, test := range tests {
+		if got := humanizeDuration(test.d); got != test.want {
+			t.Errorf("humanizeDuration(%s) = %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+func TestHumanizeETA(t *testing.T) {
+	// Monday morning, so the week's days are all ahead
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		lang string
+		eta  time.Time
+		want string
+	}{
+		{"en", now.Add(-time.Minute), "should be finishing any time now"},
+		{"en", now, "should be finishing any time now"},
+		{"en", now.Add(30 * time.Second), "less than a minute left"},
+		{"en", now.Add(time.Minute), "about 1 minute left, done today"},
+		{"en", now.Add(2 * time.Hour), "about 2 hours left, done today"},
+		{"en", now.Add(24 * time.Hour), "about 1 day left, done tomorrow"},
+		{"en", now.Add(72 * time.Hour), "about 3 days left, done around Thursday"},
+		{"de", now.Add(30 * time.Second), "in weniger als einer Minute fertig"},
+		{"de", now.Add(2 * time.Hour), "noch etwa 2 Stunden, heute fertig"},
+		{"de", now.Add(72 * time.Hour), "noch etwa 3 Tage, fertig am Donnerstag"},
+	}
+	for _, test := range tests {
+		setLanguage(t, test.lang)
+		if got := humanizeETA(test.eta, now); got != test.want {
+			t.Errorf("%s: humanizeETA(now+%s) = %q, want %q", test.lang, test.eta.Sub(now), got, test.want)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	later := time.Date(2026, 5, 12, 9, 0, 0, 0, time.Local)
+	nextYear := time.Date(2027, 10, 1, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		lang string
+		got  func() string
+		want string
+	}{
+		{"en", func() string { return shortDate(now) }, "Mon, Mar 2"},
+		{"de", func() string { return shortDate(now) }, "Mo, 2. Mär"},
+		{"de", func() string { return humanizeDay(later, now) }, "Di, 12. Mai"},
+		{"de", func() string { return humanizeDay(nextYear, now) }, "1. Okt 2027"},
+		{"de", func() string { return humanizeDue(now.Add(48*time.Hour), now) }, "fällig am Mittwoch"},
+		{"de", func() string { return humanizeDue(now.Add(-3*time.Hour), now) }, "3 Stunden überfällig"},
+		{"de", func() string { return humanizeFromNow(now.Add(-time.Minute), now) }, "1 Minute her"},
+		// Languages without a catalog fall back to English
+		{"fr", func() string { return humanizeDue(now.Add(48*time.Hour), now) }, "due Wednesday"},
+		{"fr", func() string { return shortDate(now) }, "Mon, Mar 2"},
+	}
+	for _, test := range tests {
+		setLanguage(t, test.lang)
+		if got := test.got(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.lang, got, test.want)
+		}
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := map[string]string{
+		"de":          "de",
+		"de_DE.UTF-8": "de",
+		"DE_AT":       "de",
+		"sr_RS@latin": "sr",
+		"en_US":       "en",
+	}
+	for locale, want := range tests {
+		if got := parseLanguage(locale); got != want {
+			t.Errorf("parseLanguage(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// messages holds translations of the phrases the CLI puts together itself,
+// such as durations and due dates, keyed by language and then by the
+// English phrase. Phrases without a translation are shown in English.
+// Agent replies need no entry, the server localizes those. Translations may
+// reorder their arguments with explicit indexes, e.g. %[2]s.
+var messages = map[string]map[string]string{
+	"de": {
+		"less than a minute":               "weniger als eine Minute",
+		"%d minute":                        "%d Minute",
+		"%d minutes":                       "%d Minuten",
+		"%d hour":                          "%d Stunde",
+		"%d hours":                         "%d Stunden",
+		"%d day":                           "%d Tag",
+		"%d days":                          "%d Tage",
+		"%d week":                          "%d Woche",
+		"%d weeks":                         "%d Wochen",
+		"%s ago":                           "%s her",
+		"in %s":                            "noch %s",
+		"overdue by %s":                    "%s überfällig",
+		"due today at %s":                  "fällig heute um %s",
+		"due tomorrow":                     "fällig morgen",
+		"due %s":                           "fällig am %s",
+		"should be finishing any time now": "sollte jeden Moment fertig sein",
+		"less than a minute left":          "in weniger als einer Minute fertig",
+		"about %s left, done today":        "noch etwa %s, heute fertig",
+		"about %s left, done tomorrow":     "noch etwa %s, morgen fertig",
+		"about %s left, done around %s":    "noch etwa %s, fertig am %s",
+		"%[1]s, %[2]s %[3]d":               "%[1]s, %[3]d. %[2]s",
+		"%[1]s %[2]d, %[3]d":               "%[2]d. %[1]s %[3]d",
+
+		"Monday":    "Montag",
+		"Tuesday":   "Dienstag",
+		"Wednesday": "Mittwoch",
+		"Thursday":  "Donnerstag",
+		"Friday":    "Freitag",
+		"Saturday":  "Samstag",
+		"Sunday":    "Sonntag",
+		"Mon":       "Mo",
+		"Tue":       "Di",
+		"Wed":       "Mi",
+		"Thu":       "Do",
+		"Fri":       "Fr",
+		"Sat":       "Sa",
+		"Sun":       "So",
+		"Mar":       "Mär",
+		"May":       "Mai",
+		"Oct":       "Okt",
+		"Dec":       "Dez",
+	},
+}
+
+// language is the default for --language, which overrides it.
+var language = messageLanguage()
+
+// messageLanguage takes the language from the locale environment the way
+// gettext does.
+func messageLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return parseLanguage(value)
+		}
+	}
+	return "en"
+}
+
+// parseLanguage reduces a locale to its language, so de_DE.UTF-8 selects
+// German.
+func parseLanguage(locale string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	lang, _, _ = strings.Cut(lang, "_")
+	return lang
+}
+
+// tr translates a phrase and fills in its arguments like fmt.Sprintf.
+func tr(phrase string, args ...interface{}) string {
+	if translated, ok := messages[language][phrase]; ok {
+		phrase = translated
+	}
+	if len(args) == 0 {
+		return phrase
+	}
+	return fmt.Sprintf(phrase, args...)
+}
+
+// trn translates a phrase about a count, picking the singular or the
+// plural form.
+func trn(n int, singular, plural string) string {
+	if n == 1 {
+		return tr(singular, n)
+	}
+	return tr(plural, n)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// errEndOfInput is returned when the user interrupts an empty prompt.
+var errEndOfInput = errors.New("end of input")
+
+// input reads lines from the terminal with editing, persistent history and
+// reverse search (Ctrl-R).
+type input struct {
+	rl *readline.Instance
+}
+
+func newInput() (*input, error) {
+	config := &readline.Config{
+		HistorySearchFold: true,
+		// Only chat messages are recorded, not intake answers
+		DisableAutoSaveHistory: true,
+	}
+	if dir, err := configDir(); err == nil {
+		config.HistoryFile = filepath.Join(dir, "history")
+	}
+
+	rl, err := readline.NewEx(config)
+	if err != nil {
+		return nil, err
+	}
+	return &input{rl: rl}, nil
+}
+
+// Stdout returns a writer for output that shouldn't break the line being
+// edited, such as notifications arriving mid-prompt.
+func (in *input) Stdout() io.Writer {
+	return in.rl.Stdout()
+}
+
+func (in *input) Close() error {
+	return in.rl.Close()
+}
+
+// ReadLine reads a single line. Ctrl-C on an empty line ends the input
+// just like Ctrl-D does.
+func (in *input) ReadLine(prompt string) (string, error) {
+	for {
+		line, err := in.readLine(prompt)
+		if errors.Is(err, readline.ErrInterrupt) {
+			if line == "" {
+				return "", errEndOfInput
+			}
+			continue
+		}
+		return line, err
+	}
+}
+
+// readLine reads a line, reporting Ctrl-C as readline.ErrInterrupt.
+func (in *input) readLine(prompt string) (string, error) {
+	in.rl.SetPrompt(prompt)
+	line, err := in.rl.Readline()
+	// Input piped in on Windows keeps the carriage return of each CRLF
+	return strings.TrimSuffix(line, "\r"), err
+}
+
+// ReadMessage reads a chat message, which may span several lines: a line
+// ending in a backslash continues on the next one, and everything between
+// a pair of ``` fences is taken verbatim, so pasted YAML or stack traces
+// arrive as one message. Ctrl-C discards the message being written, so a
+// paste that went wrong isn't sent half-way.
+func (in *input) ReadMessage(prompt string) (string, error) {
+	var lines []string
+	fenced := false
+	linePrompt := prompt
+
+	for {
+		line, err := in.readLine(linePrompt)
+		if errors.Is(err, readline.ErrInterrupt) {
+			if len(lines) == 0 && line == "" {
+				return "", errEndOfInput
+			}
+			if len(lines) > 0 {
+				fmt.Fprintln(in.Stdout(), "Message discarded")
+			}
+			lines, fenced, linePrompt = nil, false, prompt
+			continue
+		}
+		if err != nil {
+			if len(lines) > 0 {
+				break
+			}
+			return "", err
+		}
+		linePrompt = "...  "
+
+		// A fence opens a block only if it isn't closed on the same line,
+		// as in ```inline```, which is an ordinary one-line message
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") && (fenced || !strings.Contains(trimmed[3:], "```")) {
+			lines = append(lines, line)
+			fenced = !fenced
+			if fenced {
+				continue
+			}
+			break
+		}
+		if fenced {
+			lines = append(lines, line)
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			lines = append(lines, strings.TrimSuffix(line, "\\"))
+			continue
+		}
+
+		lines = append(lines, line)
+		break
+	}
+
+	message := strings.Join(lines, "\n")
+	if strings.TrimSpace(message) != "" {
+		in.rl.SaveHistory(message)
+	}
+	return message, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chzyer/readline"
+)
+
+// testInput reads from typed as if it came from a pipe, without history.
+func testInput(t *testing.T, typed string) *input {
+	t.Helper()
+	rl, err := readline.NewEx(&readline.Config{
+		Stdin:                  io.NopCloser(strings.NewReader(typed)),
+		Stdout:                 io.Discard,
+		Stderr:                 io.Discard,
+		FuncIsTerminal:         func() bool { return false },
+		DisableAutoSaveHistory: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rl.Close() })
+	return &input{rl: rl}
+}
+
+// readMessages reads n messages. It doesn't read on to the end of the
+// input, readline doesn't report the end of a reader that isn't a file.
+func readMessages(in *input, n int) ([]string, error) {
+	var messages []string
+	for i := 0; i < n; i++ {
+		message, err := in.ReadMessage("> ")
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		description string
+		typed       string
+		want        []string
+	}{
+		{"one line each", "hello\nthanks\n", []string{"hello", "thanks"}},
+		{"continued lines", "first \\\nsecond\nnext\n", []string{"first \nsecond", "next"}},
+		{"fenced block", "```\nkind: Pod\n  name: x\\\n```\nnext\n", []string{"```\nkind: Pod\n  name: x\\\n```", "next"}},
+		{"fence with a language", "```yaml\na: 1\n```\n", []string{"```yaml\na: 1\n```"}},
+		{"fence closed on the same line", "```inline```\nnext\n", []string{"```inline```", "next"}},
+		{"text before a fence", "see this: \\\n```\nerror\n```\n", []string{"see this: \n```\nerror\n```"}},
+		{"indented closing fence", "```\nerror\n  ```\nnext\n", []string{"```\nerror\n  ```", "next"}},
+		{"Ctrl-C discards a fenced block", "```\nhalf a paste\x03fixed\n", []string{"fixed"}},
+		{"Ctrl-C discards continued lines", "first \\\n\x03again\n", []string{"again"}},
+	}
+	for _, test := range tests {
+		got, err := readMessages(testInput(t, test.typed), len(test.want))
+		if err != nil {
+			t.Fatalf("%s: %v", test.description, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.description, got, test.want)
+		}
+	}
+}
+
+func TestReadMessageCtrlCOnEmptyPromptEndsInput(t *testing.T) {
+	in := testInput(t, "\x03hello\n")
+	if _, err := in.ReadMessage("> "); !errors.Is(err, errEndOfInput) {
+		t.Errorf("expected errEndOfInput, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// completeIdentity makes sure userID, username and email are set. Values
+// given as flags win, then the local profile, and anything still missing is
+// asked for on the terminal. The result is written back to the profile.
+func completeIdentity(in *input) error {
+	profile, err := loadProfile()
+	if err != nil {
+		fmt.Printf("Warning: ignoring local profile: %v\n", err)
+		profile = &Profile{}
+	}
+
+	if userID == "" {
+		userID = profile.UserID
+	}
+	if username == "" {
+		username = profile.Username
+	}
+	if email == "" {
+		email = profile.Email
+	}
+
+	if userID == "" {
+		userID, err = prompt(in, "User ID", "", validateUserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Offer directory values as defaults for the remaining fields
+	var suggested ldapUser
+	if (username == "" || email == "") && ldapURL != "" {
+		suggested, err = lookupLDAPUser(userID)
+		if err != nil {
+			fmt.Printf("Warning: LDAP lookup failed: %v\n", err)
+		}
+	}
+
+	if username == "" {
+		username, err = prompt(in, "Username", suggested.Username, validateRequired)
+		if err != nil {
+			return err
+		}
+	}
+	if email == "" {
+		email, err = prompt(in, "Email", suggested.Email, validateEmail)
+		if err != nil {
+			return err
+		}
+	}
+
+	updated := *profile
+	updated.UserID, updated.Username, updated.Email = userID, username, email
+	if updated != *profile {
+		if err := saveProfile(&updated); err != nil {
+			fmt.Printf("Warning: failed to save profile: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// prompt asks for a single value until it passes validation. An empty
+// answer selects the default, if there is one.
+func prompt(in *input, label, def string, validate func(string) error) (string, error) {
+	for {
+		question := label + ": "
+		if def != "" {
+			question = fmt.Sprintf("%s [%s]: ", label, def)
+		}
+		line, err := in.ReadLine(question)
+		if err != nil {
+			return "", fmt.Errorf("no value entered for %s", strings.ToLower(label))
+		}
+
+		value := strings.TrimSpace(line)
+		if value == "" {
+			value = def
+		}
+		if err := validate(value); err != nil {
+			fmt.Printf("  %v\n", err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+func validateRequired(value string) error {
+	if value == "" {
+		return errors.New("a value is required")
+	}
+	return nil
+}
+
+func validateUserID(value string) error {
+	if err := validateRequired(value); err != nil {
+		return err
+	}
+	if strings.ContainsAny(value, " \t/") {
+		return errors.New("user ID can't contain spaces or slashes")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	if err := validateRequired(value); err != nil {
+		return err
+	}
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		return fmt.Errorf("'%s' isn't a valid email address", value)
+	}
+	return nil
+}
+
+type ldapUser struct {
+	Username string
+	Email    string
+}
+
+// lookupLDAPUser finds the directory entry for a user ID so its name and
+// mail attributes can be suggested during intake.
+func lookupLDAPUser(uid string) (ldapUser, error) {
+	conn, err := ldap.DialURL(ldapURL)
+	if err != nil {
+		return ldapUser{}, err
+	}
+	defer conn.Close()
+
+	request := ldap.NewSearchRequest(
+		ldapBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 1, 5, false,
+		fmt.Sprintf("(uid=%s)", ldap.EscapeFilter(uid)),
+		[]string{"uid", "mail"},
+		nil,
+	)
+	result, err := conn.Search(request)
+	if err != nil {
+		return ldapUser{}, err
+	}
+	if len(result.Entries) == 0 {
+		return ldapUser{}, fmt.Errorf("no entry for uid '%s'", uid)
+	}
+
+	entry := result.Entries[0]
+	return ldapUser{
+		Username: entry.GetAttributeValue("uid"),
+		Email:    entry.GetAttributeValue("mail"),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDEnv tells a replacement process which inherited file descriptor
+// holds the listening socket.
+const listenFDEnv = "ONBOARDING_AGENT_LISTEN_FD"
+
+// readyFDEnv tells a replacement process which inherited file descriptor to
+// report on once it is about to serve, so the previous process only drains
+// then.
+const readyFDEnv = "ONBOARDING_AGENT_READY_FD"
+
+// listen returns the server's listening socket: one inherited from the
+// previous process during a restart, one passed by systemd socket
+// activation, or a newly bound one.
+func listen(ctx context.Context, addr string) (net.Listener, error) {
+	if listener, err := inheritedListener(); listener != nil || err != nil {
+		return listener, err
+	}
+
+	config := net.ListenConfig{}
+	if reusePort {
+		config.Control = reusePortControl
+	}
+	return config.Listen(ctx, "tcp", addr)
+}
+
+func inheritedListener() (net.Listener, error) {
+	fd := 0
+	if value := os.Getenv(listenFDEnv); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s'", listenFDEnv, value)
+		}
+		fd = n
+		os.Unsetenv(listenFDEnv)
+	} else if os.Getenv("LISTEN_FDS") == "1" && os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) {
+		// systemd passes activated sockets starting at descriptor 3
+		fd = 3
+	}
+	if fd == 0 {
+		return nil, nil
+	}
+
+	file := os.NewFile(uintptr(fd), "listener")
+	if file == nil {
+		return nil, fmt.Errorf("inherited descriptor %d is not valid", fd)
+	}
+	defer file.Close()
+	return net.FileListener(file)
+}
+
+// signalReady tells the process that started this one during a restart, and
+// systemd, that the server is about to accept connections.
+func signalReady() error {
+	if value := os.Getenv(readyFDEnv); value != "" {
+		os.Unsetenv(readyFDEnv)
+		fd, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s '%s'", readyFDEnv, value)
+		}
+		file := os.NewFile(uintptr(fd), "ready")
+		if file == nil {
+			return fmt.Errorf("inherited descriptor %d is not valid", fd)
+		}
+		defer file.Close()
+		if _, err := file.Write([]byte{1}); err != nil {
+			return err
+		}
+	}
+	return notifySystemd("READY=1")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// reloadableLogger is a logging.Logger whose level can be changed while the
+// server runs. Everything holding it, including the OCM connection, picks up
+// the new level immediately.
+type reloadableLogger struct {
+	current atomic.Value // logging.Logger
+}
+
+func newReloadableLogger(level string) (*reloadableLogger, error) {
+	logger := &reloadableLogger{}
+	if err := logger.SetLevel(level); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// SetLevel replaces the underlying logger with one logging at the given
+// level: debug, info, warn or error.
+func (l *reloadableLogger) SetLevel(level string) error {
+	rank, ok := logLevels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level '%s'", level)
+	}
+
+	logger, err := logging.NewGoLoggerBuilder().
+		Debug(rank <= 0).
+		Info(rank <= 1).
+		Warn(rank <= 2).
+		Error(true).
+		Build()
+	if err != nil {
+		return err
+	}
+	l.current.Store(logging.Logger(logger))
+	return nil
+}
+
+var logLevels = map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+func (l *reloadableLogger) get() logging.Logger {
+	return l.current.Load().(logging.Logger)
+}
+
+func (l *reloadableLogger) DebugEnabled() bool { return l.get().DebugEnabled() }
+func (l *reloadableLogger) InfoEnabled() bool  { return l.get().InfoEnabled() }
+func (l *reloadableLogger) WarnEnabled() bool  { return l.get().WarnEnabled() }
+func (l *reloadableLogger) ErrorEnabled() bool { return l.get().ErrorEnabled() }
+
+func (l *reloadableLogger) Debug(ctx context.Context, format string, args ...interface{}) {
+	l.get().Debug(ctx, format, args...)
+}
+
+func (l *reloadableLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	l.get().Info(ctx, format, args...)
+}
+
+func (l *reloadableLogger) Warn(ctx context.Context, format string, args ...interface{}) {
+	l.get().Warn(ctx, format, args...)
+}
+
+func (l *reloadableLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	l.get().Error(ctx, format, args...)
+}
+
+func (l *reloadableLogger) Fatal(ctx context.Context, format string, args ...interface{}) {
+	l.get().Fatal(ctx, format, args...)
+}
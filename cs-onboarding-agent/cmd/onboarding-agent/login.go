@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// Credentials is the login state stored next to the profile. The token is
+// only ever sent to the API URL it was obtained for.
+type Credentials struct {
+	APIURL   string        `json:"api_url"`
+	ClientID string        `json:"client_id"`
+	TokenURL string        `json:"token_url"`
+	Token    *oauth2.Token `json:"token"`
+}
+
+func credentialsPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.json"), nil
+}
+
+func loadCredentials() (*Credentials, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := &Credentials{}
+	if err := json.Unmarshal(data, credentials); err != nil {
+		return nil, fmt.Errorf("can't parse credentials '%s': %w", path, err)
+	}
+	return credentials, nil
+}
+
+func saveCredentials(credentials *Credentials) error {
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// authorize adds the stored bearer token to requests for the server the
+// user logged in to, refreshing it first if it has expired.
+func authorize(req *http.Request, apiURL string) error {
+	credentials, err := loadCredentials()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if credentials.Token == nil || strings.TrimSuffix(credentials.APIURL, "/") != strings.TrimSuffix(apiURL, "/") {
+		return nil
+	}
+
+	config := &oauth2.Config{
+		ClientID: credentials.ClientID,
+		Endpoint: oauth2.Endpoint{TokenURL: credentials.TokenURL},
+	}
+	token, err := config.TokenSource(context.Background(), credentials.Token).Token()
+	if err != nil {
+		return fmt.Errorf("stored login has expired, run 'onboarding-agent login' again: %w", err)
+	}
+	if token.AccessToken != credentials.Token.AccessToken {
+		credentials.Token = token
+		if err := saveCredentials(credentials); err != nil {
+			return err
+		}
+	}
+
+	token.SetAuthHeader(req)
+	return nil
+}
+
+// useProfileAPIURL replaces the default --api-url with the server saved by
+// 'login', unless the flag was given explicitly.
+func useProfileAPIURL(cmd *cobra.Command) {
+	flag := cmd.Flags().Lookup("api-url")
+	if flag == nil || flag.Changed {
+		return
+	}
+
+	profile, err := loadProfile()
+	if err == nil && profile.APIURL != "" {
+		apiURL = profile.APIURL
+	}
+}
+
+type oidcConfiguration struct {
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+func discoverOIDC(ctx context.Context, issuer string) (*oidcConfiguration, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery at '%s' returned %s", url, resp.Status)
+	}
+
+	discovery := &oidcConfiguration{}
+	if err := json.NewDecoder(resp.Body).Decode(discovery); err != nil {
+		return nil, err
+	}
+	if discovery.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("issuer '%s' doesn't support the device authorization flow", issuer)
+	}
+	return discovery, nil
+}
+
+func runLogin(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	// Checked before logging in, saving over a profile that can't be read
+	// would lose the identity stored in it
+	profile, err := loadProfile()
+	if err != nil {
+		fmt.Printf("Failed to load profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	discovery, err := discoverOIDC(ctx, issuerURL)
+	if err != nil {
+		fmt.Printf("Failed to contact identity provider: %v\n", err)
+		os.Exit(1)
+	}
+
+	config := &oauth2.Config{
+		ClientID: clientID,
+		Scopes:   []string{"openid", "offline_access"},
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: discovery.DeviceAuthorizationEndpoint,
+			TokenURL:      discovery.TokenEndpoint,
+		},
+	}
+
+	deviceAuth, err := config.DeviceAuth(ctx)
+	if err != nil {
+		fmt.Printf("Failed to start login: %v\n", err)
+		os.Exit(1)
+	}
+
+	if deviceAuth.VerificationURIComplete != "" {
+		fmt.Printf("Open %s in your browser to log in\n", deviceAuth.VerificationURIComplete)
+	} else {
+		fmt.Printf("Open %s in your browser and enter the code %s\n", deviceAuth.VerificationURI, deviceAuth.UserCode)
+	}
+	fmt.Println("Waiting for confirmation...")
+
+	token, err := config.DeviceAccessToken(ctx, deviceAuth)
+	if err != nil {
+		fmt.Printf("Login failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	credentials := &Credentials{
+		APIURL:   apiURL,
+		ClientID: clientID,
+		TokenURL: discovery.TokenEndpoint,
+		Token:    token,
+	}
+	if err := saveCredentials(credentials); err != nil {
+		fmt.Printf("Failed to save credentials: %v\n", err)
+		os.Exit(1)
+	}
+
+	profile.APIURL = apiURL
+	if err := saveProfile(profile); err != nil {
+		fmt.Printf("Failed to save profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Logged in to %s\n", apiURL)
+}
+
+func runLogout(cmd *cobra.Command, args []string) {
+	path, err := credentialsPath()
+	if err != nil {
+		fmt.Printf("Failed to log out: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Failed to log out: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Logged out")
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"syscall"
 	"time"
@@ -16,29 +18,77 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/spf13/cobra"
 	
-	sdk "github.com/openshift-online/ocm-sdk-go"
-	"github.com/openshift-online/ocm-sdk-go/logging"
-	
+	"github.com/openshift-online/ocm-cluster-service/pkg/featureflag"
 	"github.com/openshift-online/ocm-cluster-service/pkg/onboarding"
 	"github.com/openshift-online/ocm-cluster-service/pkg/servicelog"
 )
 
 var (
-	port        string
+	port              string
+	configFile        string
+	featureFlagsFile  string
+	logLevel          string
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	maxBodyBytes      int64
+	maxUploadBytes    int64
+	maxMessageChars   int
+	attachmentScanCmd string
+	drainTimeout      time.Duration
+	reusePort         bool
+	ocmFlags          ocmConfig
+	errorDocsURL      string
+	chaos             bool
+	chaosFlags        chaosRule
+	admissionFlags    admissionConfig
+	airGappedFlags    airGappedConfig
+	contentDir        string
+	adminTokenFile    string
+	auditLogFile      string
+	bundlePublicKey   string
+
 	interactive bool
 	userID      string
 	username    string
 	email       string
 	apiURL      string
+	ldapURL     string
+	ldapBaseDN  string
+	issuerURL   string
+	clientID    string
+
+	maxAttachmentSize int64
+	rawOutput         bool
+	desktopNotify     bool
+	timezone          string
+	workingHours      string
+	guest             bool
+	statusDetailed    bool
 )
 
 func main() {
+	enableVirtualTerminal()
+
 	rootCmd := &cobra.Command{
 		Use:   "onboarding-agent",
 		Short: "Team onboarding agent for CS service",
 		Long:  "An interactive onboarding agent that guides new team members through setup and first tasks",
+		// Commands talking to the API default to the server saved by 'login'
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			useProfileAPIURL(cmd)
+			language = parseLanguage(language)
+		},
 	}
 
+	rootCmd.PersistentFlags().BoolVar(&rawOutput, "raw", false, "Print agent responses as plain text without markdown rendering")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", noEmojiDefault(), "Use ASCII instead of emoji, always the case when output isn't a terminal (env ONBOARDING_NO_EMOJI)")
+	rootCmd.PersistentFlags().StringVar(&theme, "theme", envOrDefault("ONBOARDING_THEME", "auto"), "Style for agent responses: auto, dark, light, dracula, tokyo-night, pink, ascii, notty or a glamour JSON style file (env ONBOARDING_THEME)")
+	rootCmd.PersistentFlags().StringVar(&language, "language", language, "Language of the durations and dates the CLI formats itself, e.g. de (default from LC_ALL, LC_MESSAGES or LANG)")
+	rootCmd.PersistentFlags().BoolVar(&accessible, "accessible", accessibleDefault(), "Screen-reader friendly output: plain text with labels, no emoji, color or animation (env ONBOARDING_ACCESSIBLE)")
+
 	// Server command
 	serverCmd := &cobra.Command{
 		Use:   "server",
@@ -46,6 +96,43 @@ func main() {
 		Run:   runServer,
 	}
 	serverCmd.Flags().StringVarP(&port, "port", "p", "8080", "Server port")
+	serverCmd.Flags().StringVar(&configFile, "config", "", "YAML file with settings that are re-read on SIGHUP")
+	serverCmd.Flags().StringVar(&featureFlagsFile, "feature-flags", "", "YAML file with feature flag rollouts, re-read on SIGHUP")
+	serverCmd.Flags().StringVar(&logLevel, "log-level", envOrDefault("LOG_LEVEL", "debug"), "Log level: debug, info, warn or error")
+	serverCmd.Flags().StringVar(&ocmFlags.URL, "ocm-url", "", "OCM gateway URL (default is the production gateway)")
+	serverCmd.Flags().StringVar(&ocmFlags.TokenURL, "ocm-token-url", "", "OpenID token URL for OCM authentication")
+	serverCmd.Flags().StringVar(&ocmFlags.ClientID, "ocm-client-id", os.Getenv("OCM_CLIENT_ID"), "OCM client ID")
+	serverCmd.Flags().StringVar(&ocmFlags.ClientSecret, "ocm-client-secret", os.Getenv("OCM_CLIENT_SECRET"), "OCM client secret (prefer the OCM_CLIENT_SECRET variable)")
+	serverCmd.Flags().StringVar(&ocmFlags.TokenFile, "ocm-token-file", "", "File containing an OCM offline or access token")
+	serverCmd.Flags().StringVar(&ocmFlags.Proxy, "ocm-proxy", "", "HTTP or HTTPS proxy for OCM requests (default uses HTTPS_PROXY)")
+	serverCmd.Flags().StringVar(&ocmFlags.TrustedCAFile, "ocm-ca-file", "", "PEM file with additional CAs trusted for the OCM gateway")
+	serverCmd.Flags().BoolVar(&ocmFlags.Insecure, "ocm-insecure", false, "Skip TLS verification for the OCM gateway (testing only)")
+	serverCmd.Flags().StringVar(&errorDocsURL, "error-docs-url", "", "Base URL of the error documentation linked from problem+json responses")
+	serverCmd.Flags().BoolVar(&chaos, "chaos", false, "Inject faults into requests for resilience testing, never use in production")
+	serverCmd.Flags().StringVar(&chaosFlags.Latency, "chaos-latency", "2s", "Delay added to requests picked for latency injection")
+	serverCmd.Flags().Float64Var(&chaosFlags.LatencyRate, "chaos-latency-rate", 0, "Fraction of requests to delay")
+	serverCmd.Flags().Float64Var(&chaosFlags.ErrorRate, "chaos-error-rate", 0, "Fraction of requests to fail with a 500")
+	serverCmd.Flags().Float64Var(&chaosFlags.DropRate, "chaos-drop-rate", 0, "Fraction of requests whose connection is dropped")
+	serverCmd.Flags().DurationVar(&readHeaderTimeout, "read-header-timeout", 10*time.Second, "Maximum time to read request headers")
+	serverCmd.Flags().DurationVar(&readTimeout, "read-timeout", 30*time.Second, "Maximum time to read a whole request")
+	serverCmd.Flags().DurationVar(&writeTimeout, "write-timeout", 60*time.Second, "Maximum time to write a response (event streams are exempt)")
+	serverCmd.Flags().DurationVar(&idleTimeout, "idle-timeout", 120*time.Second, "How long idle keep-alive connections are kept open")
+	serverCmd.Flags().IntVar(&maxHeaderBytes, "max-header-bytes", 64<<10, "Maximum size of request headers")
+	serverCmd.Flags().Int64Var(&maxBodyBytes, "max-body-bytes", 1<<20, "Maximum size of a JSON request body")
+	serverCmd.Flags().DurationVar(&drainTimeout, "drain-timeout", 30*time.Second, "How long open requests may finish during shutdown or restart")
+	serverCmd.Flags().BoolVar(&reusePort, "reuse-port", false, "Bind with SO_REUSEPORT so a new instance can start on the same port before this one exits")
+	serverCmd.Flags().Int64Var(&maxUploadBytes, "max-upload-bytes", 10<<20, "Maximum size of a multipart upload such as an attachment")
+	serverCmd.Flags().IntVar(&maxMessageChars, "max-message-chars", 8000, "Maximum length of a chat message")
+	serverCmd.Flags().StringVar(&attachmentScanCmd, "attachment-scan-command", "", "Command that gets every attachment on stdin and exits non-zero to reject it, e.g. 'clamdscan --no-summary -'")
+	serverCmd.Flags().IntVar(&admissionFlags.MaxConcurrent, "max-concurrent-messages", 64, "Messages processed at once, 0 disables admission control")
+	serverCmd.Flags().IntVar(&admissionFlags.MaxWaiting, "max-waiting-messages", 256, "Messages that may wait for a slot before new ones are shed with a 503")
+	serverCmd.Flags().StringVar(&admissionFlags.WaitTimeout, "message-wait-timeout", "10s", "How long a message may wait for a slot")
+	serverCmd.Flags().BoolVar(&airGappedFlags.Enabled, "air-gapped", false, "Refuse outbound requests except to the OCM mirror and --allowed-host")
+	serverCmd.Flags().StringVar(&adminTokenFile, "admin-token-file", "", "File with the token admin requests must send in the X-Admin-Token header; admin endpoints are disabled without it")
+	serverCmd.Flags().StringVar(&auditLogFile, "audit-log", "", "File the audit log of configuration changes is appended to (default stderr)")
+	serverCmd.Flags().StringVar(&contentDir, "content-dir", "", "Directory that content bundles sent with 'bundle load' are staged in, not yet read by the flow engine")
+	serverCmd.Flags().StringVar(&bundlePublicKey, "bundle-public-key", "", "PEM file with the ed25519 public keys trusted to sign content bundles")
+	serverCmd.Flags().StringArrayVar(&airGappedFlags.AllowedHosts, "allowed-host", nil, "Host, host:port or *.domain reachable in air-gapped mode (repeatable)")
 
 	// Interactive CLI command
 	interactiveCmd := &cobra.Command{
@@ -57,17 +144,62 @@ func main() {
 	interactiveCmd.Flags().StringVar(&username, "username", "", "Username for onboarding")
 	interactiveCmd.Flags().StringVar(&email, "email", "", "Email for onboarding")
 	interactiveCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	interactiveCmd.Flags().Int64Var(&maxAttachmentSize, "max-attachment-size", 10<<20, "Largest file accepted by /attach, in bytes")
+	interactiveCmd.Flags().StringVar(&ldapURL, "ldap-url", os.Getenv("ONBOARDING_LDAP_URL"), "LDAP server used to suggest username and email")
+	interactiveCmd.Flags().StringVar(&ldapBaseDN, "ldap-base-dn", "dc=redhat,dc=com", "LDAP base DN for user lookups")
 
 	// Status command
 	statusCmd := &cobra.Command{
 		Use:   "status [session-id]",
 		Short: "Get onboarding session status",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		Run:   runStatus,
 	}
 	statusCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	statusCmd.Flags().BoolVar(&statusDetailed, "detailed", false, "Also list the tickets, invites and resources the session is waiting on")
+
+	interactiveCmd.Flags().StringVar(&timezone, "timezone", localTimezone(), "IANA timezone used for reminders and digests")
+	interactiveCmd.Flags().StringVar(&workingHours, "working-hours", "", "Working hours for reminders, e.g. 09:00-17:00 (default is the team's)")
+	interactiveCmd.Flags().BoolVar(&guest, "guest", false, "Try a limited demo session without real user details, it expires on its own and creates no records")
+	interactiveCmd.Flags().BoolVar(&desktopNotify, "notify", false, "Raise desktop notifications for mentor replies, ticket updates and nudges")
+
+	// Watch command
+	watchCmd := &cobra.Command{
+		Use:   "watch [session-id]",
+		Short: "Wait for notifications on a session, defaulting to the most recent one",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runWatch,
+	}
+	watchCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	watchCmd.Flags().BoolVar(&desktopNotify, "desktop", true, "Raise desktop notifications as well as the terminal bell")
+
+	// Timeline command
+	timelineCmd := &cobra.Command{
+		Use:   "timeline [session-id]",
+		Short: "Show the history of a session, defaulting to the most recent one",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runTimeline,
+	}
+	timelineCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+
+	// Login command
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in to the onboarding server and remember it as the default",
+		Run:   runLogin,
+	}
+	loginCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	loginCmd.Flags().StringVar(&issuerURL, "issuer-url", "https://sso.redhat.com/auth/realms/redhat-external", "OIDC issuer used for the device flow")
+	loginCmd.Flags().StringVar(&clientID, "client-id", "onboarding-agent", "OIDC client ID")
+
+	// Logout command
+	logoutCmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Remove stored credentials",
+		Run:   runLogout,
+	}
 
-	rootCmd.AddCommand(serverCmd, interactiveCmd, statusCmd)
+	rootCmd.AddCommand(serverCmd, interactiveCmd, statusCmd, timelineCmd, watchCmd, loginCmd, logoutCmd, newSessionsCommand(), newDLQCommand(), newArtifactsCommand(), newDoctorCommand(), newHandoverCommand(), newResumeCommand(), newObserveCommand(), newBundleCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
@@ -77,18 +209,35 @@ func main() {
 func runServer(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
 	
+	config, err := loadServerConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	activeConfig.Store(config)
+
+	flags, err := featureflag.Load(featureFlagsFile)
+	if err != nil {
+		log.Fatalf("Failed to load feature flags: %v", err)
+	}
+	features.Store(flags)
+
 	// Initialize logging
-	logger, err := logging.NewGoLoggerBuilder().
-		Debug(true).
-		Build()
+	logger, err := newReloadableLogger(config.LogLevel)
 	if err != nil {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
 
+	// In air-gapped mode the clients the server builds only reach the
+	// allowed hosts
+	var egress *egressGuard
+	if config.AirGapped.Enabled {
+		allowed := config.AirGapped.allowedHosts(config.OCM)
+		egress = newEgressGuard(allowed, logger)
+		logger.Info(ctx, "Air-gapped mode enabled, OCM requests are limited to %s", strings.Join(allowed, ", "))
+	}
+
 	// Initialize OCM SDK connection for service logging
-	connection, err := sdk.NewConnectionBuilder().
-		Logger(logger).
-		Build()
+	connection, err := buildOCMConnection(config.OCM, egress, logger)
 	if err != nil {
 		log.Fatalf("Failed to create OCM connection: %v", err)
 	}
@@ -103,16 +252,71 @@ func runServer(cmd *cobra.Command, args []string) {
 	// Create onboarding service
 	onboardingService := onboarding.NewOnboardingService(serviceLogClient, logger)
 
+	// Content bundles are only accepted if they can be verified
+	var installer *bundleInstaller
+	if contentDir != "" {
+		if bundlePublicKey == "" {
+			log.Fatalf("--content-dir needs --bundle-public-key to verify bundles")
+		}
+		keys, err := loadPublicKeys(bundlePublicKey)
+		if err != nil {
+			log.Fatalf("Failed to load bundle public keys: %v", err)
+		}
+		installer = &bundleInstaller{dir: filepath.Clean(contentDir), keys: keys}
+	}
+
+	if auditLogFile != "" {
+		if err := audit.open(auditLogFile); err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+	}
+
+	adminToken := ""
+	if adminTokenFile != "" {
+		adminToken, err = loadAdminToken(adminTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to load admin token: %v", err)
+		}
+	}
+
 	// Setup HTTP router
 	router := mux.NewRouter()
+	router.Use(requestIDMiddleware)
 	router.Use(onboardingService.LoggingMiddleware)
+	router.Use(bodyLimitMiddleware)
+	router.Use(adminMiddleware(adminToken))
+	router.Use(screeningMiddleware(logger))
+	router.Use(maintenance.middleware)
+	router.Use(admissionMiddleware(logger))
+	if chaos {
+		logger.Warn(ctx, "Chaos mode enabled, requests will randomly be delayed, failed or dropped")
+		router.Use(chaosMiddleware(logger))
+	}
 	
 	// Register onboarding routes
 	onboardingService.RegisterRoutes(router)
 
-	// Add CORS headers for development
+	// Admin endpoints, guarded by adminMiddleware like the service's own
+	admin := router.PathPrefix("/api/v1/admin").Subrouter()
+	admin.HandleFunc("/config/reload", configReloadHandler(logger)).Methods(http.MethodPost)
+	admin.HandleFunc("/features", featuresHandler).Methods(http.MethodGet)
+	admin.HandleFunc("/maintenance", maintenanceHandler).Methods(http.MethodGet, http.MethodPost)
+	admin.HandleFunc("/bundle", bundleLoadHandler(installer, logger)).Methods(http.MethodPost)
+	admin.HandleFunc("/workers", workersHandler).Methods(http.MethodGet)
+
+	// Messages queued during maintenance are replayed through the router
+	maintenance.handler = router
+	maintenance.logger = logger
+	maintenance.metrics = registerWorker("maintenance replay", maintenance.queueLength)
+	maintenance.Set(config.Maintenance)
+
+	// Add CORS headers for development, never for the admin endpoints
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, adminPathPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
@@ -126,108 +330,258 @@ func runServer(cmd *cobra.Command, args []string) {
 		})
 	})
 
+	// Streaming requests derive from this context so they can be ended if
+	// they outlive the drain period
+	baseCtx, cancelRequests := context.WithCancel(ctx)
+	defer cancelRequests()
+
 	// Start server
 	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+
+	listener, err := listen(ctx, server.Addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on port %s: %v", port, err)
 	}
 
-	// Start session cleanup background task
-	go onboardingService.StartSessionCleanup(ctx)
+	// Start session cleanup background task, restarted if it panics and
+	// stopped once the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	go superviseWorker(workerCtx, logger, "session cleanup", onboardingService.StartSessionCleanup)
+
+	// Reload configuration on SIGHUP
+	go func() {
+		hupChan := make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		for range hupChan {
+			reloadConfig(ctx, logger, "SIGHUP")
+		}
+	}()
 
-	// Graceful shutdown
+	// Graceful shutdown. On a restart signal a replacement process is started
+	// on the same socket first, so no connection is refused while this one
+	// drains.
+	drained := make(chan struct{})
 	go func() {
+		defer close(drained)
+
 		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-		<-sigChan
+		signal.Notify(sigChan, shutdownSignals...)
+		if restartSignal != nil {
+			signal.Notify(sigChan, restartSignal)
+		}
+		for sig := range sigChan {
+			if sig != restartSignal {
+				break
+			}
+			if err := startReplacement(listener); err != nil {
+				logger.Error(ctx, "Failed to start replacement process: %v", err)
+				continue
+			}
+			logger.Info(ctx, "Replacement process is serving, draining connections")
+			break
+		}
 
 		logger.Info(ctx, "Shutting down server...")
-		
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		stopWorkers()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
 		defer cancel()
-		
+
 		if err := server.Shutdown(shutdownCtx); err != nil {
-			logger.Error(ctx, "Failed to shutdown server: %v", err)
+			logger.Error(ctx, "Failed to drain connections within %s: %v", drainTimeout, err)
+			cancelRequests()
+			server.Close()
 		}
 	}()
 
 	logger.Info(ctx, "Starting onboarding agent server on port %s", port)
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+	if err := signalReady(); err != nil {
+		logger.Warn(ctx, "Failed to report readiness: %v", err)
+	}
+	if err := server.Serve(listener); err != http.ErrServerClosed {
 		log.Fatalf("Failed to start server: %v", err)
 	}
+	<-drained
 }
 
 func runInteractive(cmd *cobra.Command, args []string) {
-	if userID == "" || username == "" || email == "" {
+	in, err := newInput()
+	if err != nil {
+		fmt.Printf("Failed to initialize terminal input: %v\n", err)
+		os.Exit(1)
+	}
+	defer in.Close()
+
+	// Fill in any identity flags that weren't given from the local profile,
+	// prompting for whatever is still missing. Guests stay anonymous.
+	if guest {
+		userID, username, email = "", "", ""
+	} else if err := completeIdentity(in); err != nil {
+		fmt.Printf("%v\n", err)
 		fmt.Println("Please provide --user-id, --username, and --email")
 		os.Exit(1)
 	}
 
-	fmt.Printf("🎉 Welcome to the CS Team Onboarding Agent!\n")
-	fmt.Printf("Starting interactive session for %s (%s)\n\n", username, email)
+	if timezone == "" {
+		// Leave it to the server's default rather than refuse to start
+		fmt.Println("Can't tell this machine's timezone, reminders will use the server's default. Pass --timezone to set yours, e.g. --timezone Europe/Berlin")
+	} else if err := validateTimezone(timezone); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if workingHours != "" {
+		if err := validateWorkingHours(workingHours); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("%sWelcome to the CS Team Onboarding Agent!\n", symbols().Welcome)
+	if guest {
+		fmt.Printf("Starting a guest demo session. Some stages are left out and no tickets, invites or service logs are created\n\n")
+	} else {
+		fmt.Printf("Starting interactive session for %s (%s)\n\n", username, email)
+	}
 
 	// Start onboarding session
-	sessionID, err := startOnboardingSession(apiURL, userID, username, email)
+	started, err := startOnboardingSession(apiURL, userID, username, email)
 	if err != nil {
 		fmt.Printf("Failed to start onboarding session: %v\n", err)
 		os.Exit(1)
 	}
+	session := &chatSession{ID: started.SessionID, Stage: started.Stage, notices: in.Stdout()}
+	if started.ExpiresAt != nil {
+		fmt.Printf("This session ends %s\n", formatTimestamp(*started.ExpiresAt, time.Now()))
+	}
+	if !guest {
+		rememberSession(session.ID)
+	}
+
+	chat(in, session)
+}
 
-	fmt.Printf("Session ID: %s\n", sessionID)
-	fmt.Printf("Type 'help' for available commands, 'status' for progress, or 'quit' to exit\n\n")
+// chat runs the interactive chat loop for a session until the user quits.
+func chat(in *input, session *chatSession) {
+	// Follow the session's events so long operations can report progress.
+	// Servers without an event stream just get a generic spinner.
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	defer stopEvents()
+	go streamEvents(eventsCtx, apiURL, session.ID, session.handleEvent)
+
+	fmt.Printf("Session ID: %s\n", session.ID)
+	fmt.Printf("Type '/help' for available commands, 'status' for progress, or 'quit' to exit\n")
+	fmt.Printf("End a line with '\\' or wrap text in ``` to send several lines at once\n\n")
 
 	// Interactive chat loop
-	scanner := bufio.NewScanner(os.Stdin)
 	for {
-		fmt.Print("You: ")
-		if !scanner.Scan() {
+		message, err := in.ReadMessage("You: ")
+		if err != nil {
 			break
 		}
 
-		message := strings.TrimSpace(scanner.Text())
+		message = strings.TrimSpace(message)
 		if message == "" {
 			continue
 		}
-		
-		if message == "quit" || message == "exit" {
-			fmt.Println("Goodbye! You can resume your onboarding session later.")
-			break
+
+		// The bare words predate slash commands and keep working
+		switch message {
+		case "quit", "exit":
+			message = "/quit"
+		case "status":
+			message = "/status"
 		}
 
-		if message == "status" {
-			if err := showStatus(apiURL, sessionID); err != nil {
-				fmt.Printf("Failed to get status: %v\n", err)
+		if strings.HasPrefix(message, "/") {
+			if quit := runSlashCommand(session, message); quit {
+				break
 			}
 			continue
 		}
 
+		message = scrubMessage(message)
+
 		// Send message to onboarding agent
-		response, err := sendMessage(apiURL, sessionID, message)
+		var response *MessageResponse
+		err = session.busy(func() (err error) {
+			response, err = sendMessage(apiURL, session.ID, message)
+			return err
+		})
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			continue
 		}
 
-		fmt.Printf("\nAgent: %s\n", response.Message)
-		
-		if len(response.NextActions) > 0 {
-			fmt.Println("\nNext actions:")
-			for _, action := range response.NextActions {
-				fmt.Printf("  • %s\n", action)
-			}
+		printMessageResponse(session, response)
+	}
+}
+
+// printMessageResponse shows an agent reply along with the next actions and
+// progress, and tracks the stage the session has moved to.
+func printMessageResponse(session *chatSession, response *MessageResponse) {
+	if response.Stage != "" {
+		session.Stage = response.Stage
+	}
+
+	fmt.Printf("\nAgent: %s\n", renderMarkdown(response.Message))
+	if response.Queued {
+		fmt.Println(separator())
+		return
+	}
+
+	if len(response.NextActions) > 0 {
+		fmt.Println("\nNext actions:")
+		for _, action := range response.NextActions {
+			fmt.Printf("  %s %s\n", symbols().Bullet, action)
 		}
-		
+	}
+
+	if len(response.Resources) > 0 {
+		fmt.Println("\nResources:")
+		for _, resource := range response.Resources {
+			printResource(resource)
+		}
+	}
+
+	if response.EstimatedCompletionAt != nil && response.Progress < 1 {
+		fmt.Printf("\nProgress: %.0f%% complete, %s\n", response.Progress*100, humanizeETA(*response.EstimatedCompletionAt, time.Now()))
+	} else {
 		fmt.Printf("\nProgress: %.0f%% complete\n", response.Progress*100)
-		fmt.Println(strings.Repeat("-", 50))
 	}
+	fmt.Println(separator())
 }
 
 func runStatus(cmd *cobra.Command, args []string) {
-	sessionID := args[0]
+	sessionID := ""
+	if len(args) > 0 {
+		sessionID = args[0]
+	} else if profile, err := loadProfile(); err == nil {
+		sessionID = profile.SessionID
+	}
+	if sessionID == "" {
+		fmt.Println("Please provide a session ID, no recent session is known")
+		os.Exit(1)
+	}
 	if err := showStatus(apiURL, sessionID); err != nil {
 		fmt.Printf("Failed to get status: %v\n", err)
 		os.Exit(1)
 	}
+	if statusDetailed {
+		if err := showDependencies(os.Stdout, apiURL, sessionID); err != nil {
+			fmt.Printf("Failed to get dependencies: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
 type StartSessionResponse struct {
@@ -235,6 +589,9 @@ type StartSessionResponse struct {
 	Message   string  `json:"message"`
 	Stage     string  `json:"stage"`
 	Progress  float64 `json:"progress"`
+
+	// ExpiresAt is set for guest sessions, which are removed automatically
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 type MessageResponse struct {
@@ -242,6 +599,37 @@ type MessageResponse struct {
 	NextActions []string `json:"next_actions"`
 	Stage       string   `json:"stage"`
 	Progress    float64  `json:"progress"`
+
+	// Timestamps returned by the status endpoint, in RFC 3339
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+	DueAt          *time.Time `json:"due_at,omitempty"`
+	// EstimatedCompletionAt is projected from how long each remaining
+	// stage has taken other new hires
+	EstimatedCompletionAt *time.Time `json:"estimated_completion_at,omitempty"`
+
+	// Queued is set when the server is in maintenance and will answer the
+	// message once it is back
+	Queued bool `json:"queued,omitempty"`
+
+	Resources []Resource `json:"resources,omitempty"`
+}
+
+// Resource is a curated link attached to a stage. The URL points at the
+// server's redirect endpoint so click-throughs are recorded before the
+// user lands on the actual document.
+type Resource struct {
+	Title string `json:"title"`
+	Kind  string `json:"kind,omitempty"` // doc, video, dashboard
+	URL   string `json:"url"`
+}
+
+func printResource(resource Resource) {
+	if resource.Kind != "" {
+		fmt.Printf("  %s %s (%s)\n    %s\n", symbols().Bullet, resource.Title, resource.Kind, resource.URL)
+		return
+	}
+	fmt.Printf("  %s %s\n    %s\n", symbols().Bullet, resource.Title, resource.URL)
 }
 
 type ApiResponse struct {
@@ -250,37 +638,32 @@ type ApiResponse struct {
 	Error   string          `json:"error,omitempty"`
 }
 
-func startOnboardingSession(apiURL, userID, username, email string) (string, error) {
-	payload := map[string]string{
+func startOnboardingSession(apiURL, userID, username, email string) (*StartSessionResponse, error) {
+	payload := map[string]interface{}{
 		"user_id":  userID,
 		"username": username,
 		"email":    email,
+		"os":       clientOS(),
+		"arch":     runtime.GOARCH,
+		"shell":    clientShell(),
 	}
-	
-	jsonData, _ := json.Marshal(payload)
-	
-	resp, err := http.Post(apiURL+"/api/v1/onboarding/start", "application/json", strings.NewReader(string(jsonData)))
-	if err != nil {
-		return "", err
+	if timezone != "" {
+		payload["timezone"] = timezone
 	}
-	defer resp.Body.Close()
-
-	var apiResp ApiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return "", err
+	if workingHours != "" {
+		payload["working_hours"] = workingHours
 	}
-
-	if !apiResp.Success {
-		return "", fmt.Errorf("API error: %s", apiResp.Error)
+	if guest {
+		payload["guest"] = true
 	}
 
 	var sessionResp StartSessionResponse
-	if err := json.Unmarshal(apiResp.Data, &sessionResp); err != nil {
-		return "", err
+	if err := callAPI(apiURL, http.MethodPost, "/api/v1/onboarding/start", payload, &sessionResp); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Agent: %s\n", sessionResp.Message)
-	return sessionResp.SessionID, nil
+	fmt.Printf("Agent: %s\n", renderMarkdown(sessionResp.Message))
+	return &sessionResp, nil
 }
 
 func sendMessage(apiURL, sessionID, message string) (*MessageResponse, error) {
@@ -288,26 +671,9 @@ func sendMessage(apiURL, sessionID, message string) (*MessageResponse, error) {
 		"session_id": sessionID,
 		"message":    message,
 	}
-	
-	jsonData, _ := json.Marshal(payload)
-	
-	resp, err := http.Post(apiURL+"/api/v1/onboarding/message", "application/json", strings.NewReader(string(jsonData)))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var apiResp ApiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
-	}
-
-	if !apiResp.Success {
-		return nil, fmt.Errorf("API error: %s", apiResp.Error)
-	}
 
 	var msgResp MessageResponse
-	if err := json.Unmarshal(apiResp.Data, &msgResp); err != nil {
+	if err := callAPI(apiURL, http.MethodPost, "/api/v1/onboarding/message", payload, &msgResp); err != nil {
 		return nil, err
 	}
 
@@ -315,26 +681,25 @@ func sendMessage(apiURL, sessionID, message string) (*MessageResponse, error) {
 }
 
 func showStatus(apiURL, sessionID string) error {
-	resp, err := http.Get(fmt.Sprintf("%s/api/v1/onboarding/status/%s", apiURL, sessionID))
-	if err != nil {
+	var statusResp MessageResponse
+	if err := callAPI(apiURL, http.MethodGet, "/api/v1/onboarding/status/"+sessionID, nil, &statusResp); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	var apiResp ApiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return err
-	}
+	fmt.Println(renderMarkdown(statusResp.Message))
 
-	if !apiResp.Success {
-		return fmt.Errorf("API error: %s", apiResp.Error)
+	now := time.Now()
+	if statusResp.StartedAt != nil {
+		fmt.Printf("Started:  %s\n", formatTimestamp(*statusResp.StartedAt, now))
 	}
-
-	var statusResp MessageResponse
-	if err := json.Unmarshal(apiResp.Data, &statusResp); err != nil {
-		return err
+	if statusResp.LastActivityAt != nil {
+		fmt.Printf("Activity: idle for %s\n", humanizeDuration(now.Sub(*statusResp.LastActivityAt)))
+	}
+	if statusResp.DueAt != nil {
+		fmt.Printf("Current stage %s\n", humanizeDue(*statusResp.DueAt, now))
+	}
+	if statusResp.EstimatedCompletionAt != nil && statusResp.Progress < 1 {
+		fmt.Printf("Estimate: %s\n", humanizeETA(*statusResp.EstimatedCompletionAt, now))
 	}
-
-	fmt.Println(statusResp.Message)
 	return nil
-}
\ No newline at end of file
+}
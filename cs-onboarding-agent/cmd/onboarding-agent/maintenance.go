@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-online/ocm-cluster-service/pkg/featureflag"
+)
+
+const (
+	defaultMaintenanceBanner = "The onboarding service is undergoing maintenance and will be back shortly."
+	queuedMessageNotice      = "Your message has been saved and will be passed on as soon as we are back, but the reply can't be shown here. Ask again then if you still need an answer."
+
+	// maintenanceQueueFlag is the feature flag that can turn off queueing
+	// chat messages during maintenance. Queueing is on when it isn't defined.
+	maintenanceQueueFlag = "maintenance-queue"
+
+	// maxQueuedMessages bounds the messages held during maintenance; later
+	// ones are refused rather than growing the queue without limit.
+	maxQueuedMessages = 10000
+
+	// maxReplayDuration bounds each replayed message, so one that hangs
+	// doesn't hold up the rest of the queue.
+	maxReplayDuration = time.Minute
+)
+
+// maintenanceConfig is the maintenance section of the configuration file.
+type maintenanceConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Banner  string `json:"banner,omitempty"`
+}
+
+type queuedRequest struct {
+	method string
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// maintenanceMode degrades the API gracefully while the store or the OCM
+// connection is being migrated: sessions stay readable, chat messages are
+// queued and answered with the banner, and everything else that would
+// change state is refused with the banner. Ending maintenance replays the
+// queue in order before new messages are let through.
+type maintenanceMode struct {
+	mu       sync.Mutex
+	enabled  bool
+	draining bool
+	banner   string
+	queue    []queuedRequest
+
+	// handler serves replayed requests, normally the whole router
+	handler http.Handler
+	logger  *reloadableLogger
+	metrics *workerMetrics
+}
+
+var maintenance = &maintenanceMode{}
+
+// replayKey marks replayed requests so the middleware doesn't queue them
+// a second time.
+type replayKey struct{}
+
+// Set turns maintenance mode on or off. Turning it off starts replaying
+// the queued messages.
+func (m *maintenanceMode) Set(config maintenanceConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.banner = config.Banner
+	if config.Enabled == m.enabled {
+		return
+	}
+	m.enabled = config.Enabled
+	if !m.enabled && len(m.queue) > 0 && !m.draining {
+		m.draining = true
+		go m.replay()
+	}
+}
+
+// status reports the state for the admin endpoint.
+func (m *maintenanceMode) status() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return map[string]interface{}{
+		"enabled": m.enabled,
+		"banner":  m.bannerLocked(),
+		"queued":  len(m.queue),
+	}
+}
+
+func (m *maintenanceMode) bannerLocked() string {
+	if m.banner == "" {
+		return defaultMaintenanceBanner
+	}
+	return m.banner
+}
+
+func (m *maintenanceMode) replay() {
+	ctx := context.WithValue(context.Background(), replayKey{}, true)
+	for {
+		m.mu.Lock()
+		if len(m.queue) == 0 || m.enabled {
+			m.draining = false
+			m.mu.Unlock()
+			return
+		}
+		queued := m.queue[0]
+		m.queue = m.queue[1:]
+		m.mu.Unlock()
+
+		// Replays run outside net/http, which would otherwise recover a
+		// panicking handler, so runJob catches panics as well as stuck ones
+		err := runJob(ctx, m.metrics, maxReplayDuration, func(ctx context.Context) error {
+			return m.replayRequest(ctx, queued)
+		})
+		if err != nil {
+			m.logger.Warn(ctx, "Replaying queued %s %s failed: %v", queued.method, queued.url, err)
+		}
+	}
+}
+
+// queueLength is the replay worker's queue depth.
+func (m *maintenanceMode) queueLength() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+// replayRequest runs one queued request through the router. The reply has
+// nowhere to go, so only failures are reported.
+func (m *maintenanceMode) replayRequest(ctx context.Context, queued queuedRequest) error {
+	req, err := http.NewRequestWithContext(ctx, queued.method, queued.url, bytes.NewReader(queued.body))
+	if err != nil {
+		return err
+	}
+	req.Header = queued.header
+	recorder := httptest.NewRecorder()
+	m.handler.ServeHTTP(recorder, req)
+	if recorder.Code >= http.StatusBadRequest {
+		return fmt.Errorf("status %d: %s", recorder.Code, strings.TrimSpace(recorder.Body.String()))
+	}
+	return nil
+}
+
+// middleware applies maintenance mode to API requests. Reads and the admin
+// endpoints always pass, the latter so maintenance can be ended again.
+func (m *maintenanceMode) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions ||
+			strings.HasPrefix(r.URL.Path, adminPathPrefix) || r.Context().Value(replayKey{}) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		m.mu.Lock()
+		if !m.enabled && !m.draining {
+			m.mu.Unlock()
+			next.ServeHTTP(w, r)
+			return
+		}
+		banner := m.bannerLocked()
+		m.mu.Unlock()
+
+		if r.URL.Path != "/api/v1/onboarding/message" {
+			w.Header().Set("Retry-After", "300")
+			writeError(w, r, http.StatusServiceUnavailable, banner)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Queueing is rolled out by session, the rest get the banner like
+		// any other change
+		var message struct {
+			SessionID string `json:"session_id"`
+		}
+		json.Unmarshal(body, &message)
+		if !features.Load().EnabledOr(maintenanceQueueFlag, featureflag.Subject{Key: message.SessionID}, true) {
+			w.Header().Set("Retry-After", "300")
+			writeError(w, r, http.StatusServiceUnavailable, banner)
+			return
+		}
+
+		queued := queuedRequest{
+			method: r.Method,
+			url:    r.URL.String(),
+			header: r.Header.Clone(),
+			body:   body,
+		}
+
+		m.mu.Lock()
+		full := len(m.queue) >= maxQueuedMessages
+		if !full {
+			m.queue = append(m.queue, queued)
+		}
+		m.mu.Unlock()
+		if full {
+			w.Header().Set("Retry-After", "300")
+			writeError(w, r, http.StatusServiceUnavailable, banner)
+			return
+		}
+
+		writeData(w, r, http.StatusAccepted, map[string]interface{}{
+			"message": banner + "\n\n" + queuedMessageNotice,
+			"queued":  true,
+		})
+	})
+}
+
+// maintenanceHandler shows the maintenance state on GET and changes it on
+// POST with a body like {"enabled": true, "banner": "..."}.
+func maintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var config maintenanceConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid maintenance settings: "+err.Error())
+			return
+		}
+		maintenance.Set(config)
+	}
+	writeData(w, r, http.StatusOK, maintenance.status())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// writeError sends an error in the same envelope the API uses for all its
+// responses, or as RFC 7807 problem details if the client asked for them.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if acceptsProblem(r) {
+		writeProblem(w, r, status, message)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ApiResponse{Success: false, Error: message})
+}
+
+// writeData sends a successful response in the API envelope.
+func writeData(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ApiResponse{Success: true, Data: raw})
+}
+
+// eventStreamPath is the prefix of the per-session event streams.
+const eventStreamPath = "/api/v1/onboarding/events/"
+
+// bodyLimitMiddleware caps request bodies so a client can't tie up the
+// server with an endless upload. Multipart uploads get the larger limit.
+// The event stream route is exempted from the write timeout since it is
+// meant to stay open. The exemption goes by route, not by what the client
+// says it accepts, so no other request can escape the timeout.
+func bodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, eventStreamPath) {
+			http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		}
+
+		config := currentConfig()
+		limit := config.MaxBodyBytes
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			limit = config.MaxUploadBytes
+		}
+		if r.ContentLength > limit {
+			writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NotificationEvent is an asynchronous event worth interrupting the user
+// for, like a mentor reply, an approved ticket or a nudge.
+type NotificationEvent struct {
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// showNotification writes the notification with a terminal bell and, if
+// enabled, raises a desktop notification as well.
+func showNotification(out io.Writer, event SessionEvent) {
+	if event.Type != "notification" {
+		return
+	}
+	var notification NotificationEvent
+	if err := json.Unmarshal(event.Data, &notification); err != nil {
+		return
+	}
+	if notification.Title == "" {
+		notification.Title = "Onboarding agent"
+	}
+
+	bell := ""
+	if stdoutIsTerminal() {
+		bell = "\a"
+	}
+	fmt.Fprintf(out, "%s%s %s: %s\n", bell, symbols().Bell, notification.Title, notification.Message)
+	if desktopNotify {
+		if err := sendDesktopNotification(notification.Title, notification.Message); err != nil {
+			fmt.Fprintf(out, "Warning: desktop notification failed: %v\n", err)
+		}
+	}
+}
+
+// toastScript shows a Windows toast through PowerShell, under PowerShell's
+// own app ID since the CLI has none registered. Title and message come in
+// through the environment so they never need quoting.
+const toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:ONBOARDING_TOAST_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:ONBOARDING_TOAST_MESSAGE)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)`
+
+// sendDesktopNotification uses the platform's notifier: notify-send on
+// Linux, osascript on macOS and a PowerShell toast on Windows.
+func sendDesktopNotification(title, message string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		return exec.Command("osascript", "-e", script).Run()
+	case "linux":
+		return exec.Command("notify-send", title, message).Run()
+	case "windows":
+		cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+		cmd.Env = append(os.Environ(), "ONBOARDING_TOAST_TITLE="+title, "ONBOARDING_TOAST_MESSAGE="+message)
+		return cmd.Run()
+	default:
+		return fmt.Errorf("desktop notifications aren't supported on %s", runtime.GOOS)
+	}
+}
+
+func runWatch(cmd *cobra.Command, args []string) {
+	sessionID := ""
+	if len(args) > 0 {
+		sessionID = args[0]
+	} else if profile, err := loadProfile(); err == nil {
+		sessionID = profile.SessionID
+	}
+	if sessionID == "" {
+		fmt.Println("Please provide a session ID, no recent session is known")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), shutdownSignals...)
+	defer stop()
+
+	fmt.Printf("Watching session %s for notifications, press Ctrl-C to stop\n", sessionID)
+
+	// Keep reconnecting, the stream drops whenever the server restarts
+	for ctx.Err() == nil {
+		err := streamEvents(ctx, apiURL, sessionID, func(event SessionEvent) {
+			showNotification(os.Stdout, event)
+		})
+		if err != nil {
+			fmt.Printf("Event stream interrupted: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// TranscriptEvent is a chat message as streamed to observers.
+type TranscriptEvent struct {
+	Role string    `json:"role"` // user or agent
+	Name string    `json:"name,omitempty"`
+	Text string    `json:"text"`
+	At   time.Time `json:"at"`
+}
+
+var observerToken string
+
+// newObserveCommand builds the 'observe' command that lets a mentor follow
+// a session's chat live without being able to send anything.
+func newObserveCommand() *cobra.Command {
+	observeCmd := &cobra.Command{
+		Use:   "observe <session-id>",
+		Short: "Watch a session's chat live, read-only, with a token from the user's /share",
+		Args:  cobra.ExactArgs(1),
+		Run:   runObserve,
+	}
+	observeCmd.Flags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	observeCmd.Flags().StringVar(&observerToken, "token", os.Getenv("ONBOARDING_OBSERVER_TOKEN"), "Observer token (env ONBOARDING_OBSERVER_TOKEN)")
+	return observeCmd
+}
+
+func runObserve(cmd *cobra.Command, args []string) {
+	if observerToken == "" {
+		fmt.Println("Please provide --token, ask the user to run /share in their session")
+		os.Exit(1)
+	}
+	sessionID := args[0]
+
+	ctx, stop := signal.NotifyContext(context.Background(), shutdownSignals...)
+	defer stop()
+
+	fmt.Printf("Observing session %s read-only, the user can see that you are watching. Press Ctrl-C to stop\n", sessionID)
+
+	// The token goes in the query so the web UI's EventSource, which can't
+	// set headers, can use the same stream
+	path := "/api/v1/onboarding/events/" + url.PathEscape(sessionID) + "?observer_token=" + url.QueryEscape(observerToken)
+	for ctx.Err() == nil {
+		err := streamEventsFrom(ctx, apiURL, path, func(event SessionEvent) {
+			showObservedEvent(os.Stdout, event)
+		})
+		if err != nil {
+			fmt.Printf("Event stream interrupted: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func showObservedEvent(out io.Writer, event SessionEvent) {
+	switch event.Type {
+	case "message":
+		var message TranscriptEvent
+		if err := json.Unmarshal(event.Data, &message); err != nil || message.Text == "" {
+			return
+		}
+		speaker := "Agent"
+		text := renderMarkdown(message.Text)
+		if message.Role == "user" {
+			speaker = message.Name
+			if speaker == "" {
+				speaker = "User"
+			}
+			text = message.Text
+		}
+		fmt.Fprintf(out, "\n[%s] %s: %s\n", message.At.Local().Format("15:04"), speaker, text)
+	case "observer":
+		showObserver(out, event)
+	case "notification":
+		var notification NotificationEvent
+		if err := json.Unmarshal(event.Data, &notification); err == nil {
+			fmt.Fprintf(out, "%s %s: %s\n", symbols().Bell, notification.Title, notification.Message)
+		}
+	}
+}
+
+// showObserver tells the user, or other observers, who joined or left.
+func showObserver(out io.Writer, event SessionEvent) {
+	var observer ObserverEvent
+	if err := json.Unmarshal(event.Data, &observer); err != nil || observer.Name == "" {
+		return
+	}
+	if observer.Joined {
+		fmt.Fprintf(out, "%s %s is now watching this session (read-only)\n", symbols().Observer, observer.Name)
+		return
+	}
+	fmt.Fprintf(out, "%s %s stopped watching this session\n", symbols().Observer, observer.Name)
+}
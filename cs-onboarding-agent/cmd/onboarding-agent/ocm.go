@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// ocmConfig holds the OCM connection settings. Unset fields keep the SDK
+// defaults, which point at the production gateway.
+type ocmConfig struct {
+	URL          string `json:"url,omitempty"`
+	TokenURL     string `json:"token_url,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	// TokenFile contains an offline or access token, e.g. a mounted secret
+	TokenFile     string `json:"token_file,omitempty"`
+	Proxy         string `json:"proxy,omitempty"`
+	TrustedCAFile string `json:"trusted_ca_file,omitempty"`
+	Insecure      bool   `json:"insecure,omitempty"`
+}
+
+// buildOCMConnection creates the OCM connection from the configuration,
+// so restricted networks and non-production gateways need no code changes.
+// In air-gapped mode egress checks every request; it is nil otherwise.
+func buildOCMConnection(config ocmConfig, egress *egressGuard, logger logging.Logger) (*sdk.Connection, error) {
+	builder := sdk.NewConnectionBuilder().
+		Logger(logger)
+
+	if config.URL != "" {
+		builder.URL(config.URL)
+	}
+	if config.TokenURL != "" {
+		builder.TokenURL(config.TokenURL)
+	}
+	if config.ClientID != "" || config.ClientSecret != "" {
+		builder.Client(config.ClientID, config.ClientSecret)
+	}
+	if config.TokenFile != "" {
+		token, err := os.ReadFile(config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read OCM token file: %w", err)
+		}
+		builder.Tokens(strings.TrimSpace(string(token)))
+	}
+	if config.TrustedCAFile != "" {
+		builder.TrustedCAFile(config.TrustedCAFile)
+	}
+	if config.Insecure {
+		builder.Insecure(true)
+	}
+
+	// The proxy is set on a transport built from the configuration rather
+	// than on a clone of the SDK's, which only works while that is still an
+	// *http.Transport. One wrapper applies both the proxy and the egress
+	// guard, so their order is fixed.
+	var proxied *http.Transport
+	if config.Proxy != "" {
+		transport, err := ocmProxyTransport(config)
+		if err != nil {
+			return nil, err
+		}
+		proxied = transport
+	}
+	if proxied != nil || egress != nil {
+		builder.TransportWrapper(func(transport http.RoundTripper) http.RoundTripper {
+			if proxied != nil {
+				transport = proxied
+			}
+			if egress != nil {
+				transport = egress.wrap(transport)
+			}
+			return transport
+		})
+	}
+
+	return builder.Build()
+}
+
+// ocmProxyTransport builds the transport for OCM requests through the
+// configured proxy, with the same TLS settings the SDK would use.
+func ocmProxyTransport(config ocmConfig) (*http.Transport, error) {
+	proxyURL, err := url.Parse(config.Proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid OCM proxy URL '%s'", config.Proxy)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Insecure}
+	if config.TrustedCAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(config.TrustedCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read OCM CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in OCM CA file '%s'", config.TrustedCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 http.ProxyURL(proxyURL),
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// symbolSet holds the decorations the CLI prints in front of results and
+// events.
+type symbolSet struct {
+	OK       string
+	Failed   string
+	Bullet   string
+	Hint     string
+	Bell     string
+	Observer string
+	Warning  string
+	Waiting  string
+	Done     string
+	Welcome  string
+
+	Stage        string
+	Verification string
+	Ticket       string
+	Event        string
+}
+
+var fancySymbols = symbolSet{
+	OK:       "✓",
+	Failed:   "✗",
+	Bullet:   "•",
+	Hint:     "→",
+	Bell:     "🔔",
+	Observer: "👀",
+	Warning:  "⚠️ ",
+	Waiting:  "⏳",
+	Done:     "✓ ",
+	Welcome:  "🎉 ",
+
+	Stage:        "🏁",
+	Verification: "🔍",
+	Ticket:       "🎫",
+	Event:        "•",
+}
+
+// asciiSymbols are used without emoji, e.g. when output goes to a log file
+// that would show them as mojibake.
+var asciiSymbols = symbolSet{
+	OK:       "[ok]",
+	Failed:   "[fail]",
+	Bullet:   "-",
+	Hint:     "->",
+	Bell:     "[!]",
+	Observer: "[observer]",
+	Warning:  "WARNING:",
+	Waiting:  "..",
+	Done:     "ok",
+	Welcome:  "",
+
+	Stage:        "[stage]",
+	Verification: "[check]",
+	Ticket:       "[ticket]",
+	Event:        "-",
+}
+
+// accessibleSymbols spell every signal out in words, so a screen reader
+// announces what it means instead of the name of a glyph.
+var accessibleSymbols = symbolSet{
+	OK:       "OK:",
+	Failed:   "FAILED:",
+	Bullet:   "-",
+	Hint:     "Hint:",
+	Bell:     "Notification:",
+	Observer: "Observer:",
+	Warning:  "Warning:",
+	Waiting:  "Waiting:",
+	Done:     "Done:",
+	Welcome:  "",
+
+	Stage:        "Stage:",
+	Verification: "Verification:",
+	Ticket:       "Ticket:",
+	Event:        "Event:",
+}
+
+var (
+	// accessible switches the CLI to screen-reader friendly output: no
+	// emoji, box drawing, animation or color, and labels on everything.
+	accessible bool
+
+	// noEmoji replaces emoji with ASCII, which also happens whenever stdout
+	// isn't a terminal or the terminal can't draw them
+	noEmoji bool
+
+	// theme is the glamour style for agent responses: auto, dark, light,
+	// dracula, tokyo-night, pink, ascii, notty or the path of a JSON style
+	theme string
+)
+
+func accessibleDefault() bool {
+	return os.Getenv("ONBOARDING_ACCESSIBLE") != ""
+}
+
+func noEmojiDefault() bool {
+	return os.Getenv("ONBOARDING_NO_EMOJI") != ""
+}
+
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// colorEnabled follows the NO_COLOR convention (https://no-color.org) and
+// never colors output that isn't going to a terminal.
+func colorEnabled() bool {
+	return os.Getenv("NO_COLOR") == "" && !accessible && stdoutIsTerminal()
+}
+
+// symbols returns the decorations for the current output mode.
+func symbols() *symbolSet {
+	switch {
+	case accessible:
+		return &accessibleSymbols
+	case noEmoji || !stdoutIsTerminal() || !terminalSupportsEmoji():
+		return &asciiSymbols
+	default:
+		return &fancySymbols
+	}
+}
+
+// separator returns the line printed between chat turns, left out in
+// accessible mode since screen readers would read it dash by dash.
+func separator() string {
+	if accessible {
+		return ""
+	}
+	return strings.Repeat("-", 50)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// clientOS names the operating system the CLI runs on so the agent can give
+// instructions for it: "darwin", "linux", "windows" or "wsl" for Linux under
+// the Windows Subsystem for Linux, whose instructions differ from both.
+func clientOS() string {
+	if runtime.GOOS != "linux" {
+		return runtime.GOOS
+	}
+	if version, err := os.ReadFile("/proc/version"); err == nil && strings.Contains(strings.ToLower(string(version)), "microsoft") {
+		return "wsl"
+	}
+	return runtime.GOOS
+}
+
+// clientShell names the user's shell, e.g. "bash", "zsh", "powershell" or
+// "cmd", so commands in the instructions can be given in its syntax. It's
+// empty if unknown.
+func clientShell() string {
+	// Set on Unix-like systems and by Git Bash on Windows
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return strings.TrimSuffix(filepath.Base(shell), ".exe")
+	}
+	if runtime.GOOS != "windows" {
+		return ""
+	}
+	// cmd.exe sets PROMPT for itself, PowerShell doesn't
+	if os.Getenv("PROMPT") != "" {
+		return "cmd"
+	}
+	return "powershell"
+}
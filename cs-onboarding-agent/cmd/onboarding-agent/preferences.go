@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NotificationPreferences says which channels (email, slack, cli) and which
+// event types (mentor_reply, ticket_update, nudge, ...) the user wants to be
+// notified through. Anything the user hasn't set inherits the tenant's
+// defaults, reported in Defaults.
+type NotificationPreferences struct {
+	Channels map[string]bool `json:"channels"`
+	Events   map[string]bool `json:"events"`
+	// Defaults lists the settings still taken from the tenant defaults, as
+	// "channels.<name>" or "events.<name>"
+	Defaults []string `json:"defaults,omitempty"`
+}
+
+func notificationsPath(sessionID string) string {
+	return "/api/v1/onboarding/notifications/" + url.PathEscape(sessionID)
+}
+
+// runNotificationsCommand shows the preferences, or with '<name> on|off'
+// turns a channel or event type on or off.
+func runNotificationsCommand(session *chatSession, args string) (bool, error) {
+	var preferences NotificationPreferences
+	if err := callAPI(apiURL, http.MethodGet, notificationsPath(session.ID), nil, &preferences); err != nil {
+		return false, err
+	}
+
+	if args == "" {
+		printNotificationPreferences(&preferences)
+		return false, nil
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") {
+		return false, fmt.Errorf("usage: /notifications [<channel or event> on|off]")
+	}
+	name, enabled := fields[0], fields[1] == "on"
+
+	var update NotificationPreferences
+	switch {
+	case hasKey(preferences.Channels, name):
+		update.Channels = map[string]bool{name: enabled}
+	case hasKey(preferences.Events, name):
+		update.Events = map[string]bool{name: enabled}
+	default:
+		return false, fmt.Errorf("unknown channel or event '%s', type /notifications for the list", name)
+	}
+
+	if err := callAPI(apiURL, http.MethodPatch, notificationsPath(session.ID), update, &preferences); err != nil {
+		return false, err
+	}
+	printNotificationPreferences(&preferences)
+	return false, nil
+}
+
+func printNotificationPreferences(preferences *NotificationPreferences) {
+	defaults := map[string]bool{}
+	for _, name := range preferences.Defaults {
+		defaults[name] = true
+	}
+
+	section := func(title, group string, values map[string]bool) {
+		fmt.Printf("\n%s:\n", title)
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			state := "off"
+			if values[name] {
+				state = "on"
+			}
+			if defaults[group+"."+name] {
+				state += " (team default)"
+			}
+			fmt.Printf("  %-16s %s\n", name, state)
+		}
+	}
+	section("Channels", "channels", preferences.Channels)
+	section("Events", "events", preferences.Events)
+	fmt.Println("\nChange one with /notifications <name> on|off")
+}
+
+func hasKey(values map[string]bool, key string) bool {
+	_, ok := values[key]
+	return ok
+}
+
+// runSnoozeCommand holds back notifications for a while, e.g. '/snooze 2h'
+// during a meeting, or ends the snooze with '/snooze off'. Escalations the
+// flow marks as critical still get through.
+func runSnoozeCommand(session *chatSession, args string) (bool, error) {
+	path := notificationsPath(session.ID) + "/snooze"
+	if args == "off" {
+		if err := callAPI(apiURL, http.MethodDelete, path, nil, nil); err != nil {
+			return false, err
+		}
+		fmt.Println("Notifications are back on")
+		return false, nil
+	}
+
+	duration, err := time.ParseDuration(args)
+	if err != nil || duration <= 0 {
+		return false, fmt.Errorf("usage: /snooze <duration>|off, e.g. /snooze 2h")
+	}
+	until := time.Now().Add(duration)
+	payload := map[string]string{"until": until.UTC().Format(time.RFC3339)}
+	if err := callAPI(apiURL, http.MethodPost, path, payload, nil); err != nil {
+		return false, err
+	}
+	fmt.Printf("Notifications snoozed until %s, only critical escalations will get through\n", formatTimestamp(until, time.Now()))
+	return false, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemDetails is an RFC 7807 error response, extended with the IDs
+// needed to find the request in logs and traces.
+type problemDetails struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+}
+
+// acceptsProblem reports whether the client listed problem+json in its
+// Accept header.
+func acceptsProblem(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == problemContentType {
+			return true
+		}
+	}
+	return false
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	title := http.StatusText(status)
+	problem := problemDetails{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: requestID(r.Context()),
+		TraceID:   traceID(r),
+	}
+	// Link to the documentation for this kind of error when one is set up
+	if errorDocsURL != "" {
+		problem.Type = strings.TrimSuffix(errorDocsURL, "/") + "#" + strings.ToLower(strings.ReplaceAll(title, " ", "-"))
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+type requestIDKey struct{}
+
+// requestIDMiddleware gives every request an ID, reusing the caller's
+// X-Request-Id if present, and echoes it in the response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if id == "" || len(id) > 128 {
+			buffer := make([]byte, 16)
+			rand.Read(buffer)
+			id = hex.EncodeToString(buffer)
+		}
+		w.Header().Set("X-Request-Id", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// traceID extracts the trace ID from a W3C traceparent header.
+func traceID(r *http.Request) string {
+	parts := strings.Split(r.Header.Get("traceparent"), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
+}
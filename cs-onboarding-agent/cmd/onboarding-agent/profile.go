@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Profile holds the details remembered between CLI runs so that the
+// identity flags only have to be supplied once.
+type Profile struct {
+	UserID   string `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	APIURL   string `json:"api_url,omitempty"`
+
+	// SessionID is the session most recently started from this machine
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// configDir returns the directory holding the CLI's local state, normally
+// ~/.config/onboarding-agent.
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("can't determine config directory: %w", err)
+	}
+	return filepath.Join(dir, "onboarding-agent"), nil
+}
+
+func profilePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "profile.json"), nil
+}
+
+// loadProfile reads the local profile. A missing file isn't an error, it
+// just yields an empty profile.
+func loadProfile() (*Profile, error) {
+	path, err := profilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Profile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("can't parse profile '%s': %w", path, err)
+	}
+	return profile, nil
+}
+
+func saveProfile(profile *Profile) error {
+	path, err := profilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// rememberSession records the session in the profile so commands like
+// 'watch' can default to it.
+func rememberSession(sessionID string) {
+	profile, err := loadProfile()
+	if err != nil || profile.SessionID == sessionID {
+		return
+	}
+	profile.SessionID = sessionID
+	if err := saveProfile(profile); err != nil {
+		fmt.Printf("Warning: failed to save profile: %v\n", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"golang.org/x/term"
+)
+
+// renderMarkdown formats an agent response for the terminal: headings,
+// lists and links are styled and fenced code blocks are syntax
+// highlighted. Output that isn't going to a terminal is left untouched so
+// it stays readable in logs and pipes, and so is accessible output, which
+// screen readers handle better than styled text.
+func renderMarkdown(text string) string {
+	if rawOutput || accessible || !stdoutIsTerminal() {
+		return text
+	}
+
+	width := 80
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		width = w
+	}
+
+	style := glamour.WithAutoStyle()
+	switch {
+	case !colorEnabled():
+		style = glamour.WithStandardStyle("notty")
+	case theme != "" && theme != "auto":
+		style = glamour.WithStylePath(theme)
+	}
+
+	renderer, err := glamour.NewTermRenderer(
+		style,
+		glamour.WithWordWrap(width-4),
+	)
+	if err != nil {
+		return text
+	}
+
+	rendered, err := renderer.Render(text)
+	if err != nil {
+		return text
+	}
+	// Glamour pads the document with blank lines; the callers already
+	// take care of spacing
+	return "\n" + strings.Trim(rendered, "\n")
+}
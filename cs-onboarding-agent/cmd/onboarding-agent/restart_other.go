@@ -0,0 +1,26 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// Socket handoff relies on descriptor inheritance, which only works on
+// Unix-like systems.
+var restartSignal os.Signal
+
+func reusePortControl(network, address string, conn syscall.RawConn) error {
+	return errors.New("--reuse-port is not supported on this platform")
+}
+
+func startReplacement(listener net.Listener) error {
+	return errors.New("restarting with socket handoff is not supported on this platform")
+}
+
+func notifySystemd(state string) error {
+	return nil
+}
@@ -0,0 +1,135 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// restartSignal asks the server to hand its socket to a freshly started
+// copy of the binary and drain.
+var restartSignal os.Signal = syscall.SIGUSR2
+
+func reusePortControl(network, address string, conn syscall.RawConn) error {
+	var sockErr error
+	err := conn.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// replacementReadyTimeout bounds how long a replacement process may take to
+// start serving before the restart is given up.
+const replacementReadyTimeout = time.Minute
+
+// startReplacement re-executes the current binary, which may have been
+// upgraded on disk, with the same arguments and the listening socket as
+// descriptor 3. It returns once the replacement reports on descriptor 4
+// that it is about to serve; if it exits or doesn't report in time it is
+// killed and an error returned, so this process keeps serving. Both
+// processes accept from the shared socket until this one stops.
+func startReplacement(listener net.Listener) error {
+	tcpListener, ok := listener.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("can't hand off listener of type %T", listener)
+	}
+	file, err := tcpListener.File()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// Passing the socket on puts it into blocking mode, which would keep
+	// Serve stuck in accept after a shutdown
+	defer setNonblock(tcpListener)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	env := []string{}
+	for _, value := range os.Environ() {
+		if !strings.HasPrefix(value, listenFDEnv+"=") && !strings.HasPrefix(value, readyFDEnv+"=") && !strings.HasPrefix(value, "LISTEN_") {
+			env = append(env, value)
+		}
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(env, listenFDEnv+"=3", readyFDEnv+"=4")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{file, readyWriter}
+	err = cmd.Start()
+	// Only the replacement may hold the write end, so the read below ends
+	// when it exits
+	readyWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	ready.SetReadDeadline(time.Now().Add(replacementReadyTimeout))
+	if _, err := ready.Read(make([]byte, 1)); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("replacement process didn't start serving within %s", replacementReadyTimeout)
+		}
+		return fmt.Errorf("replacement process exited before it started serving")
+	}
+
+	// Under systemd the replacement takes over as the main process, or the
+	// service would be considered stopped once this one exits
+	if err := notifySystemd(fmt.Sprintf("MAINPID=%d", cmd.Process.Pid)); err != nil {
+		return fmt.Errorf("can't hand the service over to the replacement process: %w", err)
+	}
+	return nil
+}
+
+func setNonblock(listener *net.TCPListener) error {
+	conn, err := listener.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = conn.Control(func(fd uintptr) {
+		sockErr = unix.SetNonblock(int(fd), true)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// notifySystemd sends a state update to systemd if the service runs with
+// Type=notify, and does nothing otherwise.
+func notifySystemd(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+const (
+	messagePath    = "/api/v1/onboarding/message"
+	attachmentPath = "/api/v1/onboarding/attachments"
+
+	// attachmentScanTimeout bounds a run of the attachment scanner, so a
+	// hung scanner doesn't hold the upload open until the write timeout.
+	attachmentScanTimeout = 30 * time.Second
+)
+
+// screeningMiddleware applies the input limits before the onboarding
+// service sees, and stores, a request. Chat messages are refused above
+// MaxMessageChars and have secrets redacted, so clients other than this
+// CLI are covered too. Attachments are passed through the
+// AttachmentScanCommand if one is configured.
+func screeningMiddleware(logger logging.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			switch r.URL.Path {
+			case messagePath:
+				if !screenMessage(w, r, logger) {
+					return
+				}
+			case attachmentPath:
+				if !scanAttachments(w, r, logger) {
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// screenMessage checks the length of the message and rewrites the body
+// with its secrets redacted. It reports false if it answered the request.
+func screenMessage(w http.ResponseWriter, r *http.Request, logger logging.Logger) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Other fields are passed on as they are
+	var fields map[string]json.RawMessage
+	var message string
+	if json.Unmarshal(body, &fields) != nil || json.Unmarshal(fields["message"], &message) != nil {
+		// Malformed requests are the service's to refuse
+		return true
+	}
+
+	limit := currentConfig().MaxMessageChars
+	if length := utf8.RuneCountInString(message); length > limit {
+		writeError(w, r, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("the message is %d characters long, the limit is %d; attach long output as a file instead", length, limit))
+		return false
+	}
+
+	redacted, kinds := redactSecrets(message)
+	if len(kinds) == 0 {
+		return true
+	}
+	logger.Warn(r.Context(), "Redacted %s from a chat message", strings.Join(kinds, ", "))
+	fields["message"], _ = json.Marshal(redacted)
+	body, err = json.Marshal(fields)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "can't screen the message")
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return true
+}
+
+// scanAttachments runs the scan command on every uploaded file. Uploads
+// that can't be scanned are refused rather than let through. It reports
+// false if it answered the request.
+func scanAttachments(w http.ResponseWriter, r *http.Request, logger logging.Logger) bool {
+	command := strings.Fields(currentConfig().AttachmentScanCommand)
+	if len(command) == 0 {
+		return true
+	}
+	// The parsed form stays on the request for the service to read
+	if err := r.ParseMultipartForm(currentConfig().MaxUploadBytes); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid upload: "+err.Error())
+		return false
+	}
+	for _, headers := range r.MultipartForm.File {
+		for _, header := range headers {
+			file, err := header.Open()
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "invalid upload: "+err.Error())
+				return false
+			}
+			output, err := runAttachmentScan(r.Context(), command, file, header.Filename, header.Header.Get("Content-Type"))
+			file.Close()
+
+			var exitErr *exec.ExitError
+			switch {
+			case err == nil:
+				continue
+			case errors.As(err, &exitErr):
+				logger.Warn(r.Context(), "Attachment scanner rejected '%s': %s", header.Filename, output)
+				writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("'%s' was rejected by the attachment scanner: %s", header.Filename, output))
+			default:
+				logger.Error(r.Context(), "Failed to scan attachment '%s': %v", header.Filename, err)
+				w.Header().Set("Retry-After", "60")
+				writeError(w, r, http.StatusServiceUnavailable, "attachments can't be scanned right now, please try again later")
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// runAttachmentScan passes the file to the scanner on stdin, with its name
+// and type in ATTACHMENT_FILENAME and ATTACHMENT_CONTENT_TYPE. A non-zero
+// exit rejects the file, the first line of the output says why.
+func runAttachmentScan(ctx context.Context, command []string, file io.Reader, name, contentType string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, attachmentScanTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = file
+	cmd.Env = append(os.Environ(), "ATTACHMENT_FILENAME="+name, "ATTACHMENT_CONTENT_TYPE="+contentType)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("the scanner didn't finish within %s", attachmentScanTimeout)
+	}
+	reason, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if reason == "" {
+		reason = "no reason given"
+	}
+	return reason, err
+}
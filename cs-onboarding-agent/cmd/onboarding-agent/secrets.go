@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretPattern recognizes one kind of credential that users tend to paste
+// into the chat while troubleshooting.
+type secretPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+	// Checked last so it doesn't swallow a placeholder from the patterns above.
+	// No word boundary in front, so DB_PASSWORD= and client_secret: match,
+	// and an optional quote for JSON and YAML.
+	{"password", regexp.MustCompile(`(?i)(password|passwd|secret|token)["']?\s*[:=]\s*[^\s\[]\S{5,}`)},
+}
+
+// redactSecrets replaces anything that looks like a credential with a
+// placeholder and returns the kinds of secrets that were found.
+func redactSecrets(text string) (string, []string) {
+	found := map[string]bool{}
+	for _, secret := range secretPatterns {
+		text = secret.Pattern.ReplaceAllStringFunc(text, func(string) string {
+			found[secret.Name] = true
+			return fmt.Sprintf("[REDACTED %s]", strings.ToUpper(secret.Name))
+		})
+	}
+
+	kinds := make([]string, 0, len(found))
+	for kind := range found {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return text, kinds
+}
+
+// scrubMessage redacts secrets from text about to leave the machine and
+// warns the user about what was removed.
+func scrubMessage(text string) string {
+	redacted, kinds := redactSecrets(text)
+	if len(kinds) > 0 {
+		fmt.Printf("%s Removed what looks like a %s before sending. Never share credentials in the chat; "+
+			"if it was real, revoke it now.\n", symbols().Warning, strings.Join(kinds, ", "))
+	}
+	return redacted
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// BulkSession is one pre-created session for an incoming hire.
+type BulkSession struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role,omitempty"`
+	Mentor    string `json:"mentor,omitempty"`
+	StartDate string `json:"start_date,omitempty"`
+}
+
+type BulkSessionRequest struct {
+	Sessions    []BulkSession `json:"sessions"`
+	SendInvites bool          `json:"send_invites"`
+}
+
+type BulkSessionResponse struct {
+	Created []struct {
+		UserID    string `json:"user_id"`
+		SessionID string `json:"session_id"`
+	} `json:"created"`
+	Failed []struct {
+		UserID string `json:"user_id"`
+		Error  string `json:"error"`
+	} `json:"failed"`
+}
+
+// DeletedSession is a soft-deleted session waiting in the trash. It can be
+// restored until PurgeAt, after which it is removed for good.
+type DeletedSession struct {
+	SessionID string    `json:"session_id"`
+	UserID    string    `json:"user_id"`
+	DeletedBy string    `json:"deleted_by"`
+	Reason    string    `json:"reason,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+var (
+	importDryRun   bool
+	importNoInvite bool
+	deleteReason   string
+)
+
+// newSessionsCommand builds the 'sessions' group of admin commands.
+func newSessionsCommand() *cobra.Command {
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage onboarding sessions (admin)",
+	}
+	sessionsCmd.PersistentFlags().StringVar(&apiURL, "api-url", "http://localhost:8080", "Onboarding API URL")
+	addAdminTokenFlag(sessionsCmd.PersistentFlags())
+
+	importCmd := &cobra.Command{
+		Use:   "import [file.csv]",
+		Short: "Pre-create sessions for a cohort from a CSV file",
+		Long: "Pre-create sessions for a cohort from a CSV file with the columns\n" +
+			"user_id, username, email and optionally role, mentor and start_date (YYYY-MM-DD).\n" +
+			"Each new hire is sent an invite email with their session link.",
+		Args: cobra.ExactArgs(1),
+		Run:  runSessionsImport,
+	}
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Validate the file without creating sessions")
+	importCmd.Flags().BoolVar(&importNoInvite, "no-invite", false, "Don't send invite emails")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [session-id...]",
+		Short: "Move sessions to the trash, they can be restored until the recovery window ends",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runSessionsDelete,
+	}
+	deleteCmd.Flags().StringVar(&deleteReason, "reason", "", "Why the sessions are deleted, recorded in the audit log")
+
+	undeleteCmd := &cobra.Command{
+		Use:   "undelete [session-id...]",
+		Short: "Restore sessions from the trash",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runSessionsUndelete,
+	}
+
+	trashCmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List deleted sessions that can still be restored",
+		Args:  cobra.NoArgs,
+		Run:   runSessionsTrash,
+	}
+
+	sessionsCmd.AddCommand(importCmd, deleteCmd, undeleteCmd, trashCmd)
+	return sessionsCmd
+}
+
+func runSessionsImport(cmd *cobra.Command, args []string) {
+	file, err := os.Open(args[0])
+	if err != nil {
+		fmt.Printf("Failed to open file: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	sessions, err := readCohortCSV(file)
+	if err != nil {
+		fmt.Printf("Invalid file '%s': %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	if importDryRun {
+		fmt.Printf("%d sessions would be created\n", len(sessions))
+		return
+	}
+
+	request := BulkSessionRequest{Sessions: sessions, SendInvites: !importNoInvite}
+	var result BulkSessionResponse
+	if err := callAPI(apiURL, http.MethodPost, "/api/v1/admin/sessions/bulk", request, &result); err != nil {
+		fmt.Printf("Failed to import sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, created := range result.Created {
+		fmt.Printf("  %s %-20s %s\n", symbols().OK, created.UserID, created.SessionID)
+	}
+	for _, failed := range result.Failed {
+		fmt.Printf("  %s %-20s %s\n", symbols().Failed, failed.UserID, failed.Error)
+	}
+	fmt.Printf("%d created, %d failed\n", len(result.Created), len(result.Failed))
+	if len(result.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readCohortCSV parses and validates the import file. Every row is checked
+// so that all problems are reported at once.
+func readCohortCSV(r io.Reader) ([]BulkSession, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	// Column counts are checked per row below, so one bad row is reported
+	// with the others instead of aborting the import
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("can't read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"user_id", "username", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column '%s'", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var sessions []BulkSession
+	var problems []string
+	seen := map[string]int{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) != len(header) {
+			problems = append(problems, fmt.Sprintf("row %d: has %d columns, the header has %d", row, len(record), len(header)))
+			continue
+		}
+
+		session := BulkSession{
+			UserID:    field(record, "user_id"),
+			Username:  field(record, "username"),
+			Email:     field(record, "email"),
+			Role:      field(record, "role"),
+			Mentor:    field(record, "mentor"),
+			StartDate: field(record, "start_date"),
+		}
+
+		rowProblems := []error{
+			validateUserID(session.UserID),
+			validateRequired(session.Username),
+			validateEmail(session.Email),
+		}
+		if session.StartDate != "" {
+			if _, err := time.Parse("2006-01-02", session.StartDate); err != nil {
+				rowProblems = append(rowProblems, fmt.Errorf("start_date '%s' isn't YYYY-MM-DD", session.StartDate))
+			}
+		}
+		if first, ok := seen[session.UserID]; ok && session.UserID != "" {
+			rowProblems = append(rowProblems, fmt.Errorf("user '%s' already appears on row %d", session.UserID, first))
+		} else {
+			seen[session.UserID] = row
+		}
+
+		if err := errors.Join(rowProblems...); err != nil {
+			problems = append(problems, fmt.Sprintf("row %d: %s", row, strings.ReplaceAll(err.Error(), "\n", "; ")))
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New("\n  " + strings.Join(problems, "\n  "))
+	}
+	if len(sessions) == 0 {
+		return nil, errors.New("no sessions found")
+	}
+	return sessions, nil
+}
+
+func runSessionsDelete(cmd *cobra.Command, args []string) {
+	query := ""
+	if deleteReason != "" {
+		query = "?reason=" + url.QueryEscape(deleteReason)
+	}
+
+	failed := false
+	for _, id := range args {
+		var deleted DeletedSession
+		if err := callAPI(apiURL, http.MethodDelete, "/api/v1/admin/sessions/"+url.PathEscape(id)+query, nil, &deleted); err != nil {
+			fmt.Printf("  %s %s: %v\n", symbols().Failed, id, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("  %s %s: Deleted, restore with 'sessions undelete' until %s\n", symbols().OK, id, deleted.PurgeAt.Local().Format(time.RFC1123))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runSessionsUndelete(cmd *cobra.Command, args []string) {
+	failed := false
+	for _, id := range args {
+		if err := callAPI(apiURL, http.MethodPost, "/api/v1/admin/sessions/"+url.PathEscape(id)+"/undelete", nil, nil); err != nil {
+			fmt.Printf("  %s %s: %v\n", symbols().Failed, id, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("  %s %s: Restored\n", symbols().OK, id)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runSessionsTrash(cmd *cobra.Command, args []string) {
+	var deleted []DeletedSession
+	if err := callAPI(apiURL, http.MethodGet, "/api/v1/admin/sessions/trash", nil, &deleted); err != nil {
+		fmt.Printf("Failed to list deleted sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(deleted) == 0 {
+		fmt.Println("The trash is empty")
+		return
+	}
+	fmt.Printf("%-24s %-16s %-16s %-20s %-20s %s\n", "SESSION", "USER", "DELETED BY", "DELETED", "PURGED", "REASON")
+	for _, session := range deleted {
+		fmt.Printf("%-24s %-16s %-16s %-20s %-20s %s\n",
+			session.SessionID, session.UserID, session.DeletedBy,
+			session.DeletedAt.Local().Format("2006-01-02 15:04:05"),
+			session.PurgeAt.Local().Format("2006-01-02 15:04:05"), session.Reason)
+	}
+}
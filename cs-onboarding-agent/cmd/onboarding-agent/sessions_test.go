@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadCohortCSV(t *testing.T) {
+	csv := "user_id,username,email,role,mentor,start_date\n" +
+		"jdoe,John Doe,jdoe@example.com,SRE,asmith,2026-04-01\n" +
+		// Optional columns may be left empty, and the header's case and
+		// spacing don't matter
+		"mroe, Mary Roe ,mroe@example.com,,,\n"
+	sessions, err := readCohortCSV(strings.NewReader(strings.Replace(csv, "user_id,username", "User_ID, Username", 1)))
+	if err != nil {
+		t.Fatalf("can't read a valid cohort: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	want := BulkSession{UserID: "jdoe", Username: "John Doe", Email: "jdoe@example.com", Role: "SRE", Mentor: "asmith", StartDate: "2026-04-01"}
+	if sessions[0] != want {
+		t.Errorf("got %+v, want %+v", sessions[0], want)
+	}
+	if sessions[1].Username != "Mary Roe" || sessions[1].Role != "" {
+		t.Errorf("unexpected second session %+v", sessions[1])
+	}
+}
+
+func TestReadCohortCSVProblems(t *testing.T) {
+	tests := []struct {
+		description string
+		csv         string
+		// every problem must be reported, each on its own line
+		want []string
+	}{
+		{
+			"missing column",
+			"user_id,username\njdoe,John Doe\n",
+			[]string{"missing required column 'email'"},
+		},
+		{
+			"rows with too few and too many columns",
+			"user_id,username,email\n" +
+				"jdoe,John Doe\n" +
+				"mroe,Mary Roe,mroe@example.com,extra\n" +
+				"asmith,Anna Smith,asmith@example.com\n",
+			[]string{"row 2: has 2 columns, the header has 3", "row 3: has 4 columns, the header has 3"},
+		},
+		{
+			"duplicates are reported against the first row",
+			"user_id,username,email\n" +
+				"jdoe,John Doe,jdoe@example.com\n" +
+				"mroe,Mary Roe,mroe@example.com\n" +
+				"jdoe,John Doe,john@example.com\n" +
+				"jdoe,Johnny,johnny@example.com\n",
+			[]string{"row 4: user 'jdoe' already appears on row 2", "row 5: user 'jdoe' already appears on row 2"},
+		},
+		{
+			"duplicate of a row that is invalid itself",
+			"user_id,username,email\n" +
+				"jdoe,John Doe,not-an-email\n" +
+				"jdoe,John Doe,jdoe@example.com\n",
+			[]string{"row 2: 'not-an-email' isn't a valid email address", "row 3: user 'jdoe' already appears on row 2"},
+		},
+		{
+			"several problems in one row",
+			"user_id,username,email,start_date\n" +
+				"j doe,,jdoe@example.com,01/04/2026\n",
+			[]string{"row 2: user ID can't contain spaces or slashes; a value is required; start_date '01/04/2026' isn't YYYY-MM-DD"},
+		},
+		{
+			"header only",
+			"user_id,username,email\n",
+			[]string{"no sessions found"},
+		},
+	}
+	for _, test := range tests {
+		_, err := readCohortCSV(strings.NewReader(test.csv))
+		if err == nil {
+			t.Errorf("%s: expected an error", test.description)
+			continue
+		}
+		lines := strings.Split(strings.TrimSpace(err.Error()), "\n")
+		if len(lines) != len(test.want) {
+			t.Errorf("%s: expected %d problems, got %q", test.description, len(test.want), err)
+			continue
+		}
+		for i, want := range test.want {
+			if got := strings.TrimSpace(lines[i]); got != want {
+				t.Errorf("%s: got %q, want %q", test.description, got, want)
+			}
+		}
+	}
+}
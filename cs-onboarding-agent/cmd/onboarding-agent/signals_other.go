@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// Windows has no SIGTERM to send a process; Ctrl-C and Ctrl-Break arrive as
+// os.Interrupt, which is the only way to stop a command gracefully.
+var shutdownSignals = []os.Signal{os.Interrupt}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// shutdownSignals end long-running commands and the server gracefully.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
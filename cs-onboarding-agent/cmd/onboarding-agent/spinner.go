@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinner shows that a long operation is running, with a status line that
+// can be updated while it spins. It draws nothing when stdout isn't a
+// terminal. In accessible mode it doesn't animate but prints each status
+// as a line of its own, so screen readers announce it.
+type spinner struct {
+	mu   sync.Mutex
+	text string
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startSpinner(text string) *spinner {
+	s := &spinner{
+		text: text,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		close(s.done)
+		return s
+	}
+	if accessible {
+		fmt.Printf("Working: %s\n", text)
+		close(s.done)
+		return s
+	}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			s.mu.Lock()
+			fmt.Printf("\r\033[K%s %s", spinnerFrames[frame%len(spinnerFrames)], s.text)
+			s.mu.Unlock()
+
+			select {
+			case <-s.stop:
+				fmt.Print("\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+func (s *spinner) SetText(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if accessible && text != s.text && term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Printf("Working: %s\n", text)
+	}
+	s.text = text
+}
+
+// Stop clears the spinner line and waits for it to be gone.
+func (s *spinner) Stop() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// TimelineEntry is one notable event in a session's history.
+type TimelineEntry struct {
+	At    time.Time `json:"at"`
+	Kind  string    `json:"kind"` // stage, verification, ticket or event
+	Title string    `json:"title"`
+	// Status is set for verifications and tickets, e.g. passed, failed,
+	// open or resolved
+	Status string `json:"status,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func runTimeline(cmd *cobra.Command, args []string) {
+	sessionID := ""
+	if len(args) > 0 {
+		sessionID = args[0]
+	} else if profile, err := loadProfile(); err == nil {
+		sessionID = profile.SessionID
+	}
+	if sessionID == "" {
+		fmt.Println("Please provide a session ID, no recent session is known")
+		os.Exit(1)
+	}
+
+	if err := showTimeline(os.Stdout, apiURL, sessionID); err != nil {
+		fmt.Printf("Failed to get timeline: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// showTimeline prints the session's history grouped by local day, oldest
+// first.
+func showTimeline(out io.Writer, apiURL, sessionID string) error {
+	var entries []TimelineEntry
+	if err := callAPI(apiURL, http.MethodGet, "/api/v1/onboarding/timeline/"+url.PathEscape(sessionID), nil, &entries); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "Nothing has happened in this session yet")
+		return nil
+	}
+
+	day := ""
+	for _, entry := range entries {
+		at := entry.At.Local()
+		// Like shortDate, with the weekday spelled out
+		heading := tr("%[1]s, %[2]s %[3]d", tr(at.Weekday().String()), tr(at.Month().String()[:3]), at.Day())
+		if heading != day {
+			if day != "" {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintln(out, heading)
+			day = heading
+		}
+
+		icon := timelineIcon(entry.Kind)
+		title := entry.Title
+		if entry.Status != "" {
+			title += " (" + entry.Status + ")"
+		}
+		fmt.Fprintf(out, "  %s  %s %s\n", at.Format("15:04"), icon, title)
+		if entry.Detail != "" {
+			fmt.Fprintf(out, "            %s\n", entry.Detail)
+		}
+	}
+	return nil
+}
+
+func timelineIcon(kind string) string {
+	switch kind {
+	case "stage":
+		return symbols().Stage
+	case "verification":
+		return symbols().Verification
+	case "ticket":
+		return symbols().Ticket
+	default:
+		return symbols().Event
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	// Windows and minimal containers have no zoneinfo database of their
+	// own, so IANA names are resolved from a copy built into the binary
+	_ "time/tzdata"
+)
+
+// localTimezone returns the IANA name of the machine's timezone, which the
+// server uses to keep reminders inside working hours, or "" if it can't be
+// told. Go only exposes the name when TZ is set, so otherwise it is asked
+// from the operating system.
+func localTimezone() string {
+	if tz := os.Getenv("TZ"); tz != "" {
+		return strings.TrimPrefix(tz, ":")
+	}
+	return systemTimezone()
+}
+
+func validateTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone '%s'", name)
+	}
+	return nil
+}
+
+// validateWorkingHours checks a range like 09:00-17:30.
+func validateWorkingHours(hours string) error {
+	start, end, ok := strings.Cut(hours, "-")
+	if !ok {
+		return fmt.Errorf("working hours '%s' should look like 09:00-17:00", hours)
+	}
+	from, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return fmt.Errorf("invalid start of working hours '%s'", start)
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return fmt.Errorf("invalid end of working hours '%s'", end)
+	}
+	if !to.After(from) {
+		return fmt.Errorf("working hours '%s' end before they start", hours)
+	}
+	return nil
+}
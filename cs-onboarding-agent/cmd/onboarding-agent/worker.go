@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	minWorkerBackoff = time.Second
+	maxWorkerBackoff = 5 * time.Minute
+)
+
+// superviseWorker runs a background worker until ctx is cancelled. If the
+// worker panics or returns early, the failure is logged and the worker is
+// restarted with exponential backoff, so one pathological session can't
+// stop the work for good. The backoff resets once the worker has run
+// longer than the maximum backoff without failing.
+func superviseWorker(ctx context.Context, logger *reloadableLogger, name string, run func(context.Context)) {
+	metrics := registerWorker(name, nil)
+	backoff := minWorkerBackoff
+	for restarts := 0; ; restarts++ {
+		started := time.Now()
+		panicked := runWorker(ctx, logger, name, run)
+		if ctx.Err() != nil {
+			return
+		}
+
+		metrics.observe(time.Since(started), false)
+		metrics.restarted()
+		if time.Since(started) > maxWorkerBackoff {
+			backoff = minWorkerBackoff
+		}
+		if panicked {
+			logger.Error(ctx, "Worker %s panicked, restart %d in %s", name, restarts+1, backoff)
+		} else {
+			logger.Warn(ctx, "Worker %s stopped unexpectedly, restart %d in %s", name, restarts+1, backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxWorkerBackoff)
+	}
+}
+
+// runWorker runs the worker once and reports whether it panicked.
+func runWorker(ctx context.Context, logger *reloadableLogger, name string, run func(context.Context)) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(ctx, "Worker %s panicked: %v\n%s", name, r, debug.Stack())
+			panicked = true
+		}
+	}()
+	run(ctx)
+	return false
+}
+
+// workerMetrics counts what a background worker has done, for
+// GET /api/v1/admin/workers. Supervised workers that run as one long loop
+// count each run that ended in a panic or an early exit as a failed job.
+type workerMetrics struct {
+	name string
+	// depth reports the jobs waiting, nil for workers without a queue
+	depth func() int
+
+	mu           sync.Mutex
+	processed    int64
+	failed       int64
+	restarts     int64
+	totalLatency time.Duration
+	maxLatency   time.Duration
+}
+
+// WorkerStatus is a snapshot of a worker's metrics.
+type WorkerStatus struct {
+	Name         string  `json:"name"`
+	QueueDepth   *int    `json:"queue_depth,omitempty"`
+	Processed    int64   `json:"processed"`
+	Failed       int64   `json:"failed"`
+	Restarts     int64   `json:"restarts,omitempty"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+var workers struct {
+	mu   sync.Mutex
+	list []*workerMetrics
+}
+
+func registerWorker(name string, depth func() int) *workerMetrics {
+	metrics := &workerMetrics{name: name, depth: depth}
+	workers.mu.Lock()
+	workers.list = append(workers.list, metrics)
+	workers.mu.Unlock()
+	return metrics
+}
+
+func (m *workerMetrics) observe(elapsed time.Duration, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.processed++
+	if !ok {
+		m.failed++
+	}
+	m.totalLatency += elapsed
+	m.maxLatency = max(m.maxLatency, elapsed)
+}
+
+func (m *workerMetrics) restarted() {
+	m.mu.Lock()
+	m.restarts++
+	m.mu.Unlock()
+}
+
+func (m *workerMetrics) status() WorkerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := WorkerStatus{
+		Name:         m.name,
+		Processed:    m.processed,
+		Failed:       m.failed,
+		Restarts:     m.restarts,
+		MaxLatencyMs: float64(m.maxLatency) / float64(time.Millisecond),
+	}
+	if m.processed > 0 {
+		status.AvgLatencyMs = float64(m.totalLatency) / float64(m.processed) / float64(time.Millisecond)
+	}
+	if m.depth != nil {
+		depth := m.depth()
+		status.QueueDepth = &depth
+	}
+	return status
+}
+
+// runJob runs one job of a worker with its own deadline, so a stuck store
+// or OCM call fails that job instead of wedging the worker. A job that
+// doesn't return by the deadline is abandoned to finish on its own, and
+// one that panics fails without taking the server down.
+func runJob(ctx context.Context, metrics *workerMetrics, timeout time.Duration, job func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panicked: %v\n%s", r, debug.Stack())
+			}
+		}()
+		done <- job(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("didn't finish within %s", timeout)
+	}
+	metrics.observe(time.Since(started), err == nil)
+	return err
+}
+
+// workersHandler reports the metrics of the background workers.
+func workersHandler(w http.ResponseWriter, r *http.Request) {
+	workers.mu.Lock()
+	statuses := make([]WorkerStatus, 0, len(workers.list))
+	for _, metrics := range workers.list {
+		statuses = append(statuses, metrics.status())
+	}
+	workers.mu.Unlock()
+	writeData(w, r, http.StatusOK, map[string]interface{}{"workers": statuses})
+}
@@ -0,0 +1,149 @@
+// Package featureflag gates new behaviors so they can be rolled out to
+// some tenants or a percentage of sessions before everyone gets them.
+//
+// Flags are defined in a YAML file:
+//
+//	flags:
+//	- name: llm-responses
+//	  tenants: [ocm-cs]
+//	  percentage: 10
+//	- name: web-ui
+//	  enabled: true
+//
+// and can be forced on or off with FEATURE_<NAME> environment variables,
+// e.g. FEATURE_LLM_RESPONSES=false.
+package featureflag
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Flag describes the rollout of a single feature.
+type Flag struct {
+	Name string `json:"name"`
+	// Enabled turns the feature on for everyone
+	Enabled bool `json:"enabled,omitempty"`
+	// Tenants always get the feature
+	Tenants []string `json:"tenants,omitempty"`
+	// Percentage of subjects, chosen by a stable hash of their key, that get
+	// the feature
+	Percentage int `json:"percentage,omitempty"`
+}
+
+// Subject is who a flag is evaluated for.
+type Subject struct {
+	Tenant string
+	// Key identifies the subject for percentage rollouts, usually the
+	// session or user ID, so the answer stays the same across calls
+	Key string
+}
+
+// Set is an immutable collection of flags. Replace the whole set to change
+// flags at runtime.
+type Set struct {
+	flags map[string]Flag
+}
+
+type file struct {
+	Flags []Flag `json:"flags"`
+}
+
+// Load reads a flag file. An empty path yields a set where only environment
+// overrides can enable anything.
+func Load(path string) (*Set, error) {
+	set := &Set{flags: map[string]Flag{}}
+	if path == "" {
+		return set, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var contents file
+	if err := yaml.UnmarshalStrict(data, &contents); err != nil {
+		return nil, fmt.Errorf("can't parse feature flags '%s': %w", path, err)
+	}
+
+	for _, flag := range contents.Flags {
+		if flag.Name == "" {
+			return nil, fmt.Errorf("feature flag without a name in '%s'", path)
+		}
+		if flag.Percentage < 0 || flag.Percentage > 100 {
+			return nil, fmt.Errorf("feature flag '%s' has percentage %d, must be between 0 and 100", flag.Name, flag.Percentage)
+		}
+		if _, exists := set.flags[flag.Name]; exists {
+			return nil, fmt.Errorf("feature flag '%s' is defined twice", flag.Name)
+		}
+		set.flags[flag.Name] = flag
+	}
+	return set, nil
+}
+
+// Enabled reports whether the feature is on for the subject. Unknown flags
+// are off.
+func (s *Set) Enabled(name string, subject Subject) bool {
+	return s.EnabledOr(name, subject, false)
+}
+
+// EnabledOr is Enabled for a flag that isn't necessarily defined: fallback
+// is returned when neither the file nor the environment sets it. Use true
+// to put a flag on behavior that already ships, so it can be rolled back
+// without being turned off for everyone who has no flag file.
+func (s *Set) EnabledOr(name string, subject Subject, fallback bool) bool {
+	if value, ok := envOverride(name); ok {
+		return value
+	}
+
+	flag, ok := s.flags[name]
+	if !ok {
+		return fallback
+	}
+	if flag.Enabled {
+		return true
+	}
+	for _, tenant := range flag.Tenants {
+		if tenant == subject.Tenant && tenant != "" {
+			return true
+		}
+	}
+	return flag.Percentage > 0 && bucket(name, subject.Key) < flag.Percentage
+}
+
+// Flags returns the defined flags sorted by name, for display.
+func (s *Set) Flags() []Flag {
+	flags := make([]Flag, 0, len(s.flags))
+	for _, flag := range s.flags {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+func envOverride(name string) (bool, bool) {
+	variable := "FEATURE_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	value, ok := os.LookupEnv(variable)
+	if !ok {
+		return false, false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false
+	}
+	return enabled, true
+}
+
+// bucket maps a subject to 0-99, salted with the flag name so different
+// flags don't all pick the same subjects.
+func bucket(name, key string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(name + ":" + key))
+	return int(hash.Sum32() % 100)
+}
@@ -0,0 +1,91 @@
+package featureflag
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPercentageBoundaries(t *testing.T) {
+	const name = "rollout"
+	keys := []string{"", "session-1", "session-2", "3f2a9c1e", "user@example.com"}
+
+	tests := []struct {
+		description string
+		// percentage is derived from the key's bucket
+		percentage func(bucket int) int
+		want       bool
+	}{
+		{"zero percent is off", func(int) int { return 0 }, false},
+		{"hundred percent is on", func(int) int { return 100 }, true},
+		{"percentage equal to the bucket is off", func(b int) int { return b }, false},
+		{"percentage one above the bucket is on", func(b int) int { return b + 1 }, true},
+	}
+	for _, test := range tests {
+		for _, key := range keys {
+			b := bucket(name, key)
+			percentage := test.percentage(b)
+			if percentage > 100 {
+				continue
+			}
+			set := &Set{flags: map[string]Flag{name: {Name: name, Percentage: percentage}}}
+			if got := set.Enabled(name, Subject{Key: key}); got != test.want {
+				t.Errorf("%s: key %q in bucket %d at %d%%: got %v, want %v", test.description, key, b, percentage, got, test.want)
+			}
+		}
+	}
+}
+
+func TestBucketRange(t *testing.T) {
+	seen := map[int]bool{}
+	for i := 0; i < 10000; i++ {
+		b := bucket("rollout", fmt.Sprint("session-", i))
+		if b < 0 || b > 99 {
+			t.Fatalf("bucket %d is outside 0-99", b)
+		}
+		seen[b] = true
+	}
+	// Every bucket is reachable, so each percent really is one percent
+	if len(seen) != 100 {
+		t.Errorf("only %d of 100 buckets were used", len(seen))
+	}
+}
+
+func TestFlagsSorted(t *testing.T) {
+	set := &Set{flags: map[string]Flag{}}
+	for _, name := range []string{"web-ui", "llm-responses", "maintenance-queue", "a"} {
+		set.flags[name] = Flag{Name: name}
+	}
+	flags := set.Flags()
+	for i := 1; i < len(flags); i++ {
+		if flags[i-1].Name > flags[i].Name {
+			t.Fatalf("flags are not sorted: %q before %q", flags[i-1].Name, flags[i].Name)
+		}
+	}
+}
+
+func TestEnabledOr(t *testing.T) {
+	set := &Set{flags: map[string]Flag{
+		"off": {Name: "off"},
+		"on":  {Name: "on", Enabled: true},
+	}}
+	tests := []struct {
+		name     string
+		fallback bool
+		want     bool
+	}{
+		{"undefined", true, true},
+		{"undefined", false, false},
+		{"off", true, false},
+		{"on", false, true},
+	}
+	for _, test := range tests {
+		if got := set.EnabledOr(test.name, Subject{Key: "session-1"}, test.fallback); got != test.want {
+			t.Errorf("%s with fallback %v: got %v, want %v", test.name, test.fallback, got, test.want)
+		}
+	}
+
+	t.Setenv("FEATURE_UNDEFINED", "false")
+	if set.EnabledOr("undefined", Subject{}, true) {
+		t.Error("the environment should override the fallback")
+	}
+}
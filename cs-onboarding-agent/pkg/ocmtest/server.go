@@ -0,0 +1,295 @@
+// Package ocmtest provides an in-process fake of the OCM endpoints used by
+// the onboarding agent: the SSO token endpoint, service logs, clusters and
+// accounts. It lets integration code run without live credentials:
+//
+//	server := ocmtest.NewServer()
+//	defer server.Close()
+//	server.AddCluster(ocmtest.Cluster{ID: "abc", Name: "sandbox"})
+//
+//	connection, err := sdk.NewConnectionBuilder().
+//		URL(server.URL).
+//		TokenURL(server.TokenURL()).
+//		Client("client", "secret").
+//		Build()
+//
+// Everything the fake receives is recorded so callers can check what was
+// sent, e.g. with ServiceLogs.
+package ocmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServiceLog is a cluster log entry as accepted by the service logs API.
+type ServiceLog struct {
+	Kind         string    `json:"kind"`
+	ID           string    `json:"id"`
+	Href         string    `json:"href"`
+	ClusterUUID  string    `json:"cluster_uuid,omitempty"`
+	ClusterID    string    `json:"cluster_id,omitempty"`
+	Subscription string    `json:"subscription_id,omitempty"`
+	Severity     string    `json:"severity,omitempty"`
+	ServiceName  string    `json:"service_name,omitempty"`
+	Summary      string    `json:"summary,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Username     string    `json:"username,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Cluster is the subset of the clusters_mgmt cluster the agent reads.
+type Cluster struct {
+	Kind       string `json:"kind"`
+	ID         string `json:"id"`
+	Href       string `json:"href"`
+	Name       string `json:"name"`
+	ExternalID string `json:"external_id,omitempty"`
+	State      string `json:"state,omitempty"`
+}
+
+// Account is the subset of the accounts_mgmt account the agent reads.
+type Account struct {
+	Kind      string `json:"kind"`
+	ID        string `json:"id"`
+	Href      string `json:"href"`
+	Username  string `json:"username"`
+	Email     string `json:"email,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	OrgID     string `json:"organization_id,omitempty"`
+}
+
+// Server is a running fake OCM gateway and SSO server.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	serviceLogs []ServiceLog
+	clusters    map[string]Cluster
+	account     *Account
+	failures    map[string]int
+}
+
+// NewServer starts a fake with no clusters and a default current account.
+func NewServer() *Server {
+	s := &Server{
+		clusters: map[string]Cluster{},
+		failures: map[string]int{},
+		account: &Account{
+			ID:       "account-1",
+			Username: "ocmtest",
+			Email:    "ocmtest@example.com",
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/api/service_logs/v1/cluster_logs", s.authenticated(s.handleClusterLogs))
+	mux.HandleFunc("/api/clusters_mgmt/v1/clusters", s.authenticated(s.handleClusters))
+	mux.HandleFunc("/api/clusters_mgmt/v1/clusters/", s.authenticated(s.handleCluster))
+	mux.HandleFunc("/api/accounts_mgmt/v1/current_account", s.authenticated(s.handleCurrentAccount))
+	s.Server = httptest.NewServer(s.injectFailures(mux))
+	return s
+}
+
+// TokenURL is the SSO token endpoint to configure in the connection
+// builder. Any client ID and secret are accepted.
+func (s *Server) TokenURL() string {
+	return s.URL + "/token"
+}
+
+// AddCluster makes a cluster visible in the clusters API.
+func (s *Server) AddCluster(cluster Cluster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cluster.Kind = "Cluster"
+	cluster.Href = "/api/clusters_mgmt/v1/clusters/" + cluster.ID
+	s.clusters[cluster.ID] = cluster
+}
+
+// SetCurrentAccount replaces the account returned for the caller.
+func (s *Server) SetCurrentAccount(account Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account = &account
+}
+
+// ServiceLogs returns the service log entries posted so far, oldest first.
+func (s *Server) ServiceLogs() []ServiceLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ServiceLog(nil), s.serviceLogs...)
+}
+
+// FailNext makes the next n requests whose path starts with prefix fail
+// with a 503, for exercising retries.
+func (s *Server) FailNext(prefix string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[prefix] += n
+}
+
+func (s *Server) injectFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		for prefix, remaining := range s.failures {
+			if remaining > 0 && strings.HasPrefix(r.URL.Path, prefix) {
+				s.failures[prefix]--
+				s.mu.Unlock()
+				writeError(w, http.StatusServiceUnavailable, "injected failure")
+				return
+			}
+		}
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "token endpoint only accepts POST")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials", "refresh_token", "password":
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported grant type")
+		return
+	}
+
+	now := time.Now()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  makeToken("Bearer", now.Add(15*time.Minute)),
+		"refresh_token": makeToken("Refresh", now.Add(10*time.Hour)),
+		"token_type":    "Bearer",
+		"expires_in":    900,
+	})
+}
+
+func (s *Server) handleClusterLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPost:
+		var entry ServiceLog
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if entry.Summary == "" || (entry.ClusterUUID == "" && entry.ClusterID == "" && entry.Subscription == "") {
+			writeError(w, http.StatusBadRequest, "summary and a cluster or subscription are required")
+			return
+		}
+		entry.Kind = "ClusterLog"
+		entry.ID = fmt.Sprintf("log-%d", len(s.serviceLogs)+1)
+		entry.Href = "/api/service_logs/v1/cluster_logs/" + entry.ID
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = time.Now().UTC()
+		}
+		s.serviceLogs = append(s.serviceLogs, entry)
+		writeJSON(w, http.StatusCreated, entry)
+
+	case http.MethodGet:
+		items := []ServiceLog{}
+		clusterID := r.URL.Query().Get("cluster_id")
+		for i := len(s.serviceLogs) - 1; i >= 0; i-- {
+			entry := s.serviceLogs[i]
+			if clusterID == "" || entry.ClusterID == clusterID || entry.ClusterUUID == clusterID {
+				items = append(items, entry)
+			}
+		}
+		writeList(w, "ClusterLogList", items, len(items))
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []Cluster{}
+	for _, cluster := range s.clusters {
+		items = append(items, cluster)
+	}
+	writeList(w, "ClusterList", items, len(items))
+}
+
+func (s *Server) handleCluster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/clusters_mgmt/v1/clusters/")
+	cluster, ok := s.clusters[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Cluster '%s' not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, cluster)
+}
+
+func (s *Server) handleCurrentAccount(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := *s.account
+	account.Kind = "Account"
+	account.Href = "/api/accounts_mgmt/v1/accounts/" + account.ID
+	writeJSON(w, http.StatusOK, account)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeList(w http.ResponseWriter, kind string, items interface{}, total int) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"kind":  kind,
+		"page":  1,
+		"size":  total,
+		"total": total,
+		"items": items,
+	})
+}
+
+// writeError replies with an OCM-style error object.
+func writeError(w http.ResponseWriter, status int, reason string) {
+	writeJSON(w, status, map[string]interface{}{
+		"kind":   "Error",
+		"id":     fmt.Sprint(status),
+		"href":   fmt.Sprintf("/api/errors/%d", status),
+		"code":   fmt.Sprintf("OCMTEST-%d", status),
+		"reason": reason,
+	})
+}
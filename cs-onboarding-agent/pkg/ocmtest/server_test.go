@@ -0,0 +1,172 @@
+package ocmtest_test
+
+import (
+	"net/http"
+	"testing"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+
+	"github.com/openshift-online/ocm-cluster-service/pkg/ocmtest"
+)
+
+// These tests drive the fake with the SDK, so they fail if the fake stops
+// speaking the protocol the agent relies on.
+
+func connect(t *testing.T, server *ocmtest.Server, builder *sdk.ConnectionBuilder) *sdk.Connection {
+	t.Helper()
+	connection, err := builder.
+		URL(server.URL).
+		TokenURL(server.TokenURL()).
+		Client("client", "secret").
+		Build()
+	if err != nil {
+		t.Fatalf("can't connect to the fake: %v", err)
+	}
+	t.Cleanup(func() { connection.Close() })
+	return connection
+}
+
+func newServer(t *testing.T) *ocmtest.Server {
+	t.Helper()
+	server := ocmtest.NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientCredentials(t *testing.T) {
+	server := newServer(t)
+	connection := connect(t, server, sdk.NewConnectionBuilder())
+
+	access, refresh, err := connection.Tokens()
+	if err != nil {
+		t.Fatalf("can't get tokens: %v", err)
+	}
+	if access == "" || refresh == "" {
+		t.Errorf("expected an access and a refresh token, got %q and %q", access, refresh)
+	}
+}
+
+func TestRequiresBearerToken(t *testing.T) {
+	server := newServer(t)
+
+	resp, err := http.Get(server.URL + "/api/clusters_mgmt/v1/clusters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+}
+
+func TestClusters(t *testing.T) {
+	server := newServer(t)
+	server.AddCluster(ocmtest.Cluster{ID: "abc", Name: "sandbox", ExternalID: "uuid-abc", State: "ready"})
+	server.AddCluster(ocmtest.Cluster{ID: "def", Name: "staging"})
+	clusters := connect(t, server, sdk.NewConnectionBuilder()).ClustersMgmt().V1().Clusters()
+
+	list, err := clusters.List().Send()
+	if err != nil {
+		t.Fatalf("can't list clusters: %v", err)
+	}
+	if list.Total() != 2 || list.Items().Len() != 2 {
+		t.Errorf("expected 2 clusters, got total %d with %d items", list.Total(), list.Items().Len())
+	}
+
+	get, err := clusters.Cluster("abc").Get().Send()
+	if err != nil {
+		t.Fatalf("can't get cluster: %v", err)
+	}
+	cluster := get.Body()
+	if cluster.Name() != "sandbox" || cluster.ExternalID() != "uuid-abc" || cluster.State() != cmv1.ClusterStateReady {
+		t.Errorf("unexpected cluster %s: name %q, external ID %q, state %q",
+			cluster.ID(), cluster.Name(), cluster.ExternalID(), cluster.State())
+	}
+
+	missing, err := clusters.Cluster("missing").Get().Send()
+	if err == nil {
+		t.Fatal("expected an error for a missing cluster")
+	}
+	if missing.Status() != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing cluster, got %d", missing.Status())
+	}
+}
+
+func TestCurrentAccount(t *testing.T) {
+	server := newServer(t)
+	server.SetCurrentAccount(ocmtest.Account{ID: "a1", Username: "jdoe", Email: "jdoe@example.com", OrgID: "org-1"})
+	connection := connect(t, server, sdk.NewConnectionBuilder())
+
+	resp, err := connection.AccountsMgmt().V1().CurrentAccount().Get().Send()
+	if err != nil {
+		t.Fatalf("can't get current account: %v", err)
+	}
+	account := resp.Body()
+	if account.ID() != "a1" || account.Username() != "jdoe" || account.Email() != "jdoe@example.com" {
+		t.Errorf("unexpected account %q: username %q, email %q", account.ID(), account.Username(), account.Email())
+	}
+}
+
+func TestServiceLogs(t *testing.T) {
+	server := newServer(t)
+	logs := connect(t, server, sdk.NewConnectionBuilder()).ServiceLogs().V1().ClusterLogs()
+
+	entry, err := slv1.NewLogEntry().
+		ClusterUUID("uuid-abc").
+		Severity(slv1.SeverityInfo).
+		ServiceName("onboarding-agent").
+		Summary("Onboarding started").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := logs.Add().Body(entry).Send()
+	if err != nil {
+		t.Fatalf("can't add service log: %v", err)
+	}
+	if added.Body().ID() == "" {
+		t.Error("expected the added entry to have an ID")
+	}
+
+	recorded := server.ServiceLogs()
+	if len(recorded) != 1 {
+		t.Fatalf("expected 1 recorded service log, got %d", len(recorded))
+	}
+	if got := recorded[0]; got.ClusterUUID != "uuid-abc" || got.Summary != "Onboarding started" || got.Severity != "Info" || got.ServiceName != "onboarding-agent" {
+		t.Errorf("recorded service log doesn't match what was sent: %+v", got)
+	}
+
+	// Entries without a summary are refused like the real API does
+	invalid, err := slv1.NewLogEntry().ClusterUUID("uuid-abc").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected, err := logs.Add().Body(invalid).Send()
+	if err == nil {
+		t.Fatal("expected an error for a service log without a summary")
+	}
+	if rejected.Status() != http.StatusBadRequest {
+		t.Errorf("expected 400 for a service log without a summary, got %d", rejected.Status())
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	server := newServer(t)
+	// Without retries the injected failure reaches the caller
+	clusters := connect(t, server, sdk.NewConnectionBuilder().RetryLimit(0)).ClustersMgmt().V1().Clusters()
+	server.FailNext("/api/clusters_mgmt/", 1)
+
+	failed, err := clusters.List().Send()
+	if err == nil {
+		t.Fatal("expected the injected failure")
+	}
+	if failed.Status() != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for the injected failure, got %d", failed.Status())
+	}
+
+	if _, err := clusters.List().Send(); err != nil {
+		t.Errorf("expected only one request to fail, got %v", err)
+	}
+}
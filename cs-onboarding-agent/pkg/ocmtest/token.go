@@ -0,0 +1,35 @@
+package ocmtest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// signingKey signs the fake's tokens. The SDK only decodes tokens to read
+// their expiry, it never verifies the signature.
+var signingKey = []byte("ocmtest")
+
+// makeToken returns a JWT of the given type ("Bearer" or "Refresh") that
+// expires at the given time.
+func makeToken(typ string, expires time.Time) string {
+	encode := func(value interface{}) string {
+		data, _ := json.Marshal(value)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	header := encode(map[string]string{"alg": "HS256", "typ": "JWT"})
+	claims := encode(map[string]interface{}{
+		"typ": typ,
+		"sub": "ocmtest",
+		"iat": time.Now().Unix(),
+		"exp": expires.Unix(),
+	})
+
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(header + "." + claims))
+	signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return header + "." + claims + "." + signature
+}